@@ -5,10 +5,14 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"slices"
 	"syscall"
 	"time"
 
+	"web-analyzer-go/internal/analyzer"
 	"web-analyzer-go/internal/api"
+	"web-analyzer-go/internal/config"
+	"web-analyzer-go/internal/factory"
 	"web-analyzer-go/internal/metrics"
 	"web-analyzer-go/internal/util"
 
@@ -18,11 +22,82 @@ import (
 func main() {
 	util.InitLogger()
 	metrics.RegisterPrometheus()
+	cfg := config.Load()
+	guard, err := factory.NewDialGuard(factory.DialGuardConfig{
+		AllowCIDRs: cfg.SSRFAllowCIDRs,
+		DenyCIDRs:  cfg.SSRFDenyCIDRs,
+		AllowHosts: cfg.SSRFAllowHosts,
+		DenyHosts:  cfg.SSRFDenyHosts,
+	})
+	if err != nil {
+		util.Logger.Error("config.invalid_dial_guard", "error", err)
+		os.Exit(1)
+	}
+	client := (&factory.DefaultHTTPClientFactory{Guard: guard}).NewClient()
+
+	var linkCache *factory.LinkCache
+	if cfg.LinkCacheSize > 0 {
+		linkCache = factory.NewLinkCache(factory.LinkCacheConfig{
+			PositiveTTL: cfg.LinkCachePositiveTTL,
+			NegativeTTL: cfg.LinkCacheNegativeTTL,
+			MaxEntries:  cfg.LinkCacheSize,
+		})
+	}
+
+	linkRetry := factory.DefaultRetryPolicy()
+	linkRetry.MaxAttempts = max(cfg.LinkCheckMaxAttempts, 1)
+	linkRetry.MaxRetryAfter = cfg.LinkCheckMaxRetryAfter
+
+	switch cfg.LinkCheckMode {
+	case analyzer.LinkCheckFull, analyzer.LinkCheckHead, analyzer.LinkCheckSample, analyzer.LinkCheckNone:
+	default:
+		util.Logger.Error("config.invalid_link_check_mode", "mode", cfg.LinkCheckMode)
+		os.Exit(1)
+	}
+	switch cfg.Robots {
+	case analyzer.RobotsIgnore, analyzer.RobotsReportOnly, analyzer.RobotsObey:
+	default:
+		util.Logger.Error("config.invalid_robots_policy", "policy", cfg.Robots)
+		os.Exit(1)
+	}
+	for name := range cfg.StrategyBudgets {
+		if !slices.ContainsFunc(analyzer.DefaultStrategies(), func(s analyzer.StrategySpec) bool { return s.Name == name }) {
+			util.Logger.Error("config.invalid_strategy_budget", "strategy", name)
+			os.Exit(1)
+		}
+	}
+	robotsConfig := factory.DefaultRobotsConfig()
+	robotsConfig.TTL = cfg.RobotsCacheTTL
+
+	a := analyzer.New(
+		analyzer.WithLogger(util.Logger),
+		analyzer.WithHTTPClient(client),
+		analyzer.WithLimits(analyzer.Limits{
+			DefaultTimeout:      cfg.DefaultTimeout,
+			MaxTimeout:          cfg.MaxTimeout,
+			DefaultMaxBodyBytes: cfg.DefaultMaxBodyBytes,
+			MaxBodyBytes:        cfg.MaxBodyBytes,
+			DefaultLinkCheck:    cfg.LinkCheckMode,
+			DefaultLinkSample:   cfg.LinkCheckSample,
+			MaxLinkSample:       cfg.LinkCheckMaxSample,
+			DefaultRobots:       cfg.Robots,
+		}),
+		analyzer.WithStrategyBudgets(cfg.StrategyBudgets),
+		analyzer.WithLinkCache(linkCache),
+		analyzer.WithLinkRetry(linkRetry),
+		analyzer.WithTrackingParams(cfg.LinkTrackingParams),
+		analyzer.WithRobotsCache(factory.NewRobotsCache(client, robotsConfig)),
+		analyzer.WithLinkScheduler(factory.NewLinkScheduler(factory.LinkSchedulerConfig{
+			MaxInFlight:  cfg.LinkCheckMaxInFlight,
+			PerHostLimit: cfg.LinkCheckPerHost,
+			PerHostDelay: cfg.LinkCheckHostDelay,
+		})),
+	)
 
-	mux := api.NewRouter()
+	mux := api.NewRouter(a)
 
 	srv := &http.Server{
-		Addr:              ":8080",
+		Addr:              cfg.Addr,
 		Handler:           mux,
 		ReadHeaderTimeout: 5 * time.Second,
 		ReadTimeout:       20 * time.Second,
@@ -39,7 +114,7 @@ func main() {
 			os.Exit(1)
 		}
 	}()
-	util.Logger.Info("server.started", "addr", ":8080")
+	util.Logger.Info("server.started", "addr", cfg.Addr)
 
 	<-ctx.Done()
 	util.Logger.Info("server.shutting_down")
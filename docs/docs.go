@@ -17,9 +17,10 @@ const docTemplate = `{
     "paths": {
         "/analyze": {
             "post": {
-                "description": "Analyzes the given URL and returns HTML version, title, headings, link stats, and login form presence.",
+                "description": "Analyzes the given URL and returns HTML version, title, headings, link stats, and login form presence.\nAn optional options object tunes timeout, body size, User-Agent, strategies and link checking.\nInstead of a URL, raw HTML can be sent in the html field (with an optional base_url for resolving\nrelative links) or uploaded as multipart/form-data in a \"file\" part. Link checking is off by default in that mode.",
                 "consumes": [
-                    "application/json"
+                    "application/json",
+                    "multipart/form-data"
                 ],
                 "produces": [
                     "application/json"
@@ -30,7 +31,7 @@ const docTemplate = `{
                 "summary": "Analyze a web page",
                 "parameters": [
                     {
-                        "description": "URL to analyze",
+                        "description": "URL or HTML to analyze",
                         "name": "analyzeRequest",
                         "in": "body",
                         "required": true,
@@ -49,13 +50,25 @@ const docTemplate = `{
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/api.errorResponse"
+                            "$ref": "#/definitions/errors.HTTPResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/errors.HTTPResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/errors.HTTPResponse"
                         }
                     },
                     "502": {
                         "description": "Bad Gateway",
                         "schema": {
-                            "$ref": "#/definitions/api.errorResponse"
+                            "$ref": "#/definitions/errors.HTTPResponse"
                         }
                     }
                 }
@@ -63,28 +76,143 @@ const docTemplate = `{
         }
     },
     "definitions": {
+        "api.analyzeOptions": {
+            "type": "object",
+            "properties": {
+                "link_check": {
+                    "type": "string",
+                    "enum": [
+                        "full",
+                        "head",
+                        "sample",
+                        "none"
+                    ]
+                },
+                "link_sample": {
+                    "type": "integer"
+                },
+                "link_scope": {
+                    "type": "string",
+                    "enum": [
+                        "host",
+                        "site"
+                    ]
+                },
+                "max_body_bytes": {
+                    "type": "integer"
+                },
+                "robots": {
+                    "type": "string",
+                    "enum": [
+                        "ignore",
+                        "report_only",
+                        "obey"
+                    ]
+                },
+                "soft_404": {
+                    "type": "boolean"
+                },
+                "sort_query": {
+                    "type": "boolean"
+                },
+                "strategies": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "strict": {
+                    "type": "boolean"
+                },
+                "timeout_ms": {
+                    "type": "integer"
+                },
+                "user_agent": {
+                    "type": "string"
+                }
+            }
+        },
         "api.analyzeRequest": {
             "type": "object",
             "properties": {
+                "base_url": {
+                    "type": "string"
+                },
+                "html": {
+                    "type": "string"
+                },
+                "options": {
+                    "$ref": "#/definitions/api.analyzeOptions"
+                },
                 "url": {
                     "type": "string"
                 }
             }
         },
-        "api.errorResponse": {
+        "errors.ErrorType": {
+            "type": "string",
+            "enum": [
+                "VALIDATION_ERROR",
+                "BAD_REQUEST",
+                "UNAUTHORIZED",
+                "FORBIDDEN",
+                "NOT_FOUND",
+                "METHOD_NOT_ALLOWED",
+                "TIMEOUT",
+                "UNSUPPORTED_MEDIA_TYPE",
+                "INTERNAL_ERROR",
+                "SERVICE_UNAVAILABLE",
+                "UPSTREAM_ERROR",
+                "PARSE_FAILURE"
+            ],
+            "x-enum-varnames": [
+                "ErrorTypeValidation",
+                "ErrorTypeBadRequest",
+                "ErrorTypeUnauthorized",
+                "ErrorTypeForbidden",
+                "ErrorTypeNotFound",
+                "ErrorTypeMethodNotAllowed",
+                "ErrorTypeTimeout",
+                "ErrorTypeUnsupportedMedia",
+                "ErrorTypeInternal",
+                "ErrorTypeUnavailable",
+                "ErrorTypeUpstream",
+                "ErrorTypeParseFailure"
+            ]
+        },
+        "errors.HTTPResponse": {
             "type": "object",
             "properties": {
+                "details": {
+                    "type": "string"
+                },
                 "error": {
                     "type": "string"
                 },
                 "status_code": {
                     "type": "integer"
+                },
+                "type": {
+                    "$ref": "#/definitions/errors.ErrorType"
                 }
             }
         },
         "model.AnalyzeResult": {
             "type": "object",
             "properties": {
+                "encoding": {
+                    "$ref": "#/definitions/model.EncodingInfo"
+                },
+                "external_domains": {
+                    "description": "ExternalDomains groups the links not counted as internal by domain.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.DomainStats"
+                    }
+                },
+                "fetch": {
+                    "$ref": "#/definitions/model.FetchInfo"
+                },
                 "headings": {
                     "type": "array",
                     "items": {
@@ -94,14 +222,120 @@ const docTemplate = `{
                 "html_version": {
                     "type": "string"
                 },
+                "link_audit": {
+                    "$ref": "#/definitions/model.LinkAudit"
+                },
+                "link_check_mode": {
+                    "description": "LinkCheckMode is the link-check mode the analysis used.",
+                    "type": "string"
+                },
+                "link_details": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.LinkDetail"
+                    }
+                },
+                "link_kinds": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/model.LinkStats"
+                    }
+                },
+                "link_sample": {
+                    "$ref": "#/definitions/model.LinkSample"
+                },
                 "links": {
                     "$ref": "#/definitions/model.LinkStats"
                 },
                 "login_form": {
                     "type": "boolean"
                 },
+                "seo": {
+                    "$ref": "#/definitions/model.SEOInfo"
+                },
+                "strategies": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.StrategyStatus"
+                    }
+                },
                 "title": {
                     "type": "string"
+                },
+                "warnings": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "model.DomainStats": {
+            "type": "object",
+            "properties": {
+                "domain": {
+                    "type": "string"
+                },
+                "https": {
+                    "type": "boolean"
+                },
+                "inaccessible": {
+                    "type": "integer"
+                },
+                "links": {
+                    "type": "integer"
+                },
+                "unique_urls": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.EncodingInfo": {
+            "type": "object",
+            "properties": {
+                "charset": {
+                    "type": "string"
+                },
+                "source": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.FetchInfo": {
+            "type": "object",
+            "properties": {
+                "bytes_read": {
+                    "type": "integer"
+                },
+                "content_length": {
+                    "type": "integer"
+                },
+                "duration_ms": {
+                    "type": "integer"
+                },
+                "final_url": {
+                    "type": "string"
+                },
+                "headers": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "protocol": {
+                    "type": "string"
+                },
+                "redirects": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Redirect"
+                    }
+                },
+                "status_code": {
+                    "type": "integer"
+                },
+                "truncated": {
+                    "type": "boolean"
                 }
             }
         },
@@ -116,9 +350,259 @@ const docTemplate = `{
                 }
             }
         },
+        "model.LinkAudit": {
+            "type": "object",
+            "properties": {
+                "download": {
+                    "type": "integer"
+                },
+                "findings": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.LinkFinding"
+                    }
+                },
+                "hreflang": {
+                    "type": "integer"
+                },
+                "nofollow": {
+                    "type": "integer"
+                },
+                "noopener": {
+                    "type": "integer"
+                },
+                "noreferrer": {
+                    "type": "integer"
+                },
+                "sponsored": {
+                    "type": "integer"
+                },
+                "target_blank": {
+                    "type": "integer"
+                },
+                "ugc": {
+                    "type": "integer"
+                },
+                "unsafe_target_blank": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.LinkBreakdown": {
+            "type": "object",
+            "properties": {
+                "auth_required": {
+                    "type": "integer"
+                },
+                "broken_fragment": {
+                    "type": "integer"
+                },
+                "client_error": {
+                    "type": "integer"
+                },
+                "connection_error": {
+                    "type": "integer"
+                },
+                "disallowed_by_robots": {
+                    "type": "integer"
+                },
+                "dns_error": {
+                    "type": "integer"
+                },
+                "ok": {
+                    "type": "integer"
+                },
+                "rate_limited": {
+                    "type": "integer"
+                },
+                "redirect": {
+                    "type": "integer"
+                },
+                "server_error": {
+                    "type": "integer"
+                },
+                "timeout": {
+                    "type": "integer"
+                },
+                "tls_error": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.LinkDetail": {
+            "type": "object",
+            "properties": {
+                "accessible": {
+                    "type": "boolean"
+                },
+                "attempts": {
+                    "type": "integer"
+                },
+                "attribute": {
+                    "type": "string"
+                },
+                "blocked": {
+                    "type": "boolean"
+                },
+                "broken_fragments": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "cached": {
+                    "type": "boolean"
+                },
+                "category": {
+                    "type": "string"
+                },
+                "checked": {
+                    "type": "boolean"
+                },
+                "download": {
+                    "type": "boolean"
+                },
+                "element": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "fragments": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "href": {
+                    "type": "string"
+                },
+                "hreflang": {
+                    "type": "string"
+                },
+                "internal": {
+                    "type": "boolean"
+                },
+                "kind": {
+                    "type": "string"
+                },
+                "latency_ms": {
+                    "type": "integer"
+                },
+                "occurrences": {
+                    "type": "integer"
+                },
+                "redirect_url": {
+                    "type": "string"
+                },
+                "rel": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "robots_disallowed": {
+                    "description": "RobotsDisallowed is set when robots.txt disallows the link for the\nanalyzer, whether or not it was requested anyway.",
+                    "type": "boolean"
+                },
+                "same_page": {
+                    "type": "boolean"
+                },
+                "site": {
+                    "type": "string"
+                },
+                "soft_404": {
+                    "description": "Soft404 flags a page that answered successfully but looks like a \"not\nfound\" page; Soft404Confidence is the detector's score from 0 to 1.",
+                    "type": "boolean"
+                },
+                "soft_404_confidence": {
+                    "type": "number"
+                },
+                "status_code": {
+                    "type": "integer"
+                },
+                "target": {
+                    "type": "string"
+                },
+                "text": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                },
+                "variants": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "model.LinkFinding": {
+            "type": "object",
+            "properties": {
+                "href": {
+                    "type": "string"
+                },
+                "issue": {
+                    "type": "string"
+                },
+                "occurrences": {
+                    "type": "integer"
+                },
+                "url": {
+                    "type": "string"
+                },
+                "value": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.LinkSample": {
+            "type": "object",
+            "properties": {
+                "checked": {
+                    "type": "integer"
+                },
+                "confidence": {
+                    "type": "number"
+                },
+                "estimated_inaccessible": {
+                    "type": "integer"
+                },
+                "high": {
+                    "type": "integer"
+                },
+                "inaccessible": {
+                    "type": "integer"
+                },
+                "low": {
+                    "type": "integer"
+                },
+                "population": {
+                    "type": "integer"
+                },
+                "size": {
+                    "type": "integer"
+                }
+            }
+        },
         "model.LinkStats": {
             "type": "object",
             "properties": {
+                "blocked": {
+                    "type": "integer"
+                },
+                "breakdown": {
+                    "$ref": "#/definitions/model.LinkBreakdown"
+                },
+                "broken": {
+                    "type": "integer"
+                },
+                "disallowed_by_robots": {
+                    "description": "DisallowedByRobots is reported with the robots report_only and obey policies.",
+                    "type": "integer"
+                },
                 "external": {
                     "type": "integer"
                 },
@@ -127,6 +611,124 @@ const docTemplate = `{
                 },
                 "internal": {
                     "type": "integer"
+                },
+                "soft_404": {
+                    "type": "integer"
+                },
+                "unchecked": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.Redirect": {
+            "type": "object",
+            "properties": {
+                "location": {
+                    "type": "string"
+                },
+                "status_code": {
+                    "type": "integer"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.SEOFinding": {
+            "type": "object",
+            "properties": {
+                "blocks_indexing": {
+                    "type": "boolean"
+                },
+                "chars": {
+                    "type": "integer"
+                },
+                "field": {
+                    "type": "string"
+                },
+                "issue": {
+                    "type": "string"
+                },
+                "pixels": {
+                    "type": "integer"
+                },
+                "value": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.SEOInfo": {
+            "type": "object",
+            "properties": {
+                "canonical": {
+                    "type": "string"
+                },
+                "description": {
+                    "$ref": "#/definitions/model.SEOText"
+                },
+                "findings": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.SEOFinding"
+                    }
+                },
+                "indexable": {
+                    "type": "boolean"
+                },
+                "keywords": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "robots": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "title": {
+                    "$ref": "#/definitions/model.SEOText"
+                },
+                "viewport": {
+                    "type": "string"
+                },
+                "x_robots_tag": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "model.SEOText": {
+            "type": "object",
+            "properties": {
+                "chars": {
+                    "type": "integer"
+                },
+                "pixels": {
+                    "type": "integer"
+                },
+                "text": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.StrategyStatus": {
+            "type": "object",
+            "properties": {
+                "duration_ms": {
+                    "type": "integer"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
                 }
             }
         }
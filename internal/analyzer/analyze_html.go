@@ -0,0 +1,84 @@
+package analyzer
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/url"
+	"time"
+
+	appErr "web-analyzer-go/internal/errors"
+	"web-analyzer-go/internal/model"
+	"web-analyzer-go/internal/util"
+
+	"golang.org/x/net/html"
+)
+
+// AnalyzeHTML runs the analysis strategies on a document supplied by the
+// caller instead of fetching it. contentType may carry a charset parameter and
+// baseURL, when set, is used to resolve relative links. Link checking defaults
+// to LinkCheckNone in this mode; request it explicitly with opts.LinkCheck.
+func (a *Analyzer) AnalyzeHTML(ctx context.Context, body []byte, contentType, baseURL string, opts Options) (*model.AnalyzeResult, error) {
+	a.logInfo("analyze.start", slog.String("mode", "html"), slog.Int("bytes", len(body)), slog.String("base_url", baseURL))
+	start := time.Now()
+
+	base := &url.URL{}
+	if baseURL != "" {
+		parsed, err := a.parseTargetURL(baseURL)
+		if err != nil {
+			return nil, err
+		}
+		// Links resolved against the base are checked over HTTP.
+		if err := checkHTTPURL(parsed); err != nil {
+			a.logInfo("analyze.invalid_url", slog.String("url", baseURL))
+			return nil, err
+		}
+		base = parsed
+	}
+
+	if opts.LinkCheck == "" {
+		opts.LinkCheck = LinkCheckNone
+	}
+	opts, err := opts.resolve(a.limits, a.strategyNames())
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil, appErr.NewValidationError("html must not be empty")
+	}
+	size := int64(len(body))
+	truncated := size > opts.MaxBodyBytes
+	if truncated {
+		body = body[:opts.MaxBodyBytes]
+		a.logInfo("html.truncated", slog.Int64("limit_bytes", opts.MaxBodyBytes))
+	}
+
+	decoded, encoding := util.DecodeHTML(body, contentType)
+	doc, err := html.Parse(bytes.NewReader(decoded))
+	if err != nil {
+		a.logError("html.parse.error", slog.String("error", ErrParseHTML.Error()))
+		return nil, appErr.NewParseError("HTML", err)
+	}
+
+	strategies, skipped := a.strategiesFor(opts, nil)
+	result, err := a.runStrategiesParallel(ctx, doc, base, strategies, opts.Strict)
+	if err != nil {
+		return nil, err
+	}
+	result.Strategies = append(result.Strategies, skipped...)
+	if opts.wants(StrategyLinks) {
+		result.LinkCheckMode = opts.LinkCheck
+	}
+	result.Encoding = encoding
+	result.Fetch = model.FetchInfo{
+		ContentLength: size,
+		BytesRead:     int64(len(body)),
+		Truncated:     truncated,
+	}
+
+	a.logAnalyzeDone(result, start)
+	return result, nil
+}
@@ -0,0 +1,32 @@
+package analyzer
+
+import (
+	"context"
+	"testing"
+)
+
+func TestAnalyzeHTML_resolvesAgainstBaseURL(t *testing.T) {
+	doc := `<!DOCTYPE html><html><head><title>Preview</title></head><body>
+	<a href="/docs">Docs</a>
+	<a href="https://other.example/">Other</a>
+	</body></html>`
+	res, err := AnalyzeHTML(context.Background(), []byte(doc), "", "https://site.example/", Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Title != "Preview" || res.HTMLVersion != "HTML5" {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	if res.Links.Internal != 1 || res.Links.External != 1 || res.Links.Inaccessible != 0 {
+		t.Fatalf("unexpected link stats: %+v", res.Links)
+	}
+}
+
+func TestAnalyzeHTML_rejectsBadInput(t *testing.T) {
+	if _, err := AnalyzeHTML(context.Background(), []byte("  "), "", "", Options{}); err == nil {
+		t.Fatalf("expected error for empty html")
+	}
+	if _, err := AnalyzeHTML(context.Background(), []byte("<p>x</p>"), "", "ftp://site.example", Options{}); err == nil {
+		t.Fatalf("expected error for non-http base_url")
+	}
+}
@@ -1,57 +1,26 @@
 package analyzer
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"log/slog"
-	"net"
 	"net/http"
 	"net/url"
 	"sync"
 	"time"
 	appErr "web-analyzer-go/internal/errors"
-	"web-analyzer-go/internal/factory"
-	"web-analyzer-go/internal/metrics"
 	"web-analyzer-go/internal/model"
-
-	"io"
+	"web-analyzer-go/internal/util"
 
 	"golang.org/x/net/html"
 	"golang.org/x/sync/errgroup"
 )
 
-// AnalyzePage fetches the target URL, parses its HTML, and runs a suite of
-// analysis strategies to produce a consolidated *model.AnalyzeResult. It is
-// safe for concurrent use and respects the provided context for request-level
-// timeouts and cancellation.
-func AnalyzePage(ctx context.Context, targetURL string) (*model.AnalyzeResult, error) {
-	logInfo("analyze.start", slog.String("url", targetURL))
-	start := time.Now()
-
-	parsed, err := parseTargetURL(targetURL)
-	if err != nil {
-		return nil, err
-	}
-
-	client := (&factory.DefaultHTTPClientFactory{}).NewClient()
-
-	req, err := buildGetRequest(ctx, targetURL)
-	if err != nil {
-		return nil, err
-	}
-
-	doc, err := fetchAndParseHTML(client, req)
-	if err != nil {
-		return nil, err
-	}
-
-	result, err := runStrategiesParallel(ctx, doc, parsed, strategiesFor(client))
-	if err != nil {
-		return nil, err
-	}
-
-	logInfo("analyze.done",
+// logAnalyzeDone logs the summary of a finished analysis and records its duration.
+func (a *Analyzer) logAnalyzeDone(result *model.AnalyzeResult, start time.Time) {
+	a.logInfo("analyze.done",
 		slog.String("html_version", result.HTMLVersion),
 		slog.String("title", result.Title),
 		slog.Bool("login_form", result.LoginForm),
@@ -60,113 +29,121 @@ func AnalyzePage(ctx context.Context, targetURL string) (*model.AnalyzeResult, e
 		slog.Int("links_inaccessible", result.Links.Inaccessible),
 		slog.Int64("duration_ms", time.Since(start).Milliseconds()),
 	)
-	metrics.ObserveAnalyzeTotalDuration(time.Since(start))
-	return result, nil
+	a.metrics.ObserveAnalyzeTotalDuration(time.Since(start))
 }
 
-// parseTargetURL validates and parses the provided URL.
-func parseTargetURL(targetURL string) (*url.URL, error) {
+// parseTargetURL validates the syntax of the provided absolute URL. Which
+// schemes can be analyzed is up to the Fetcher.
+func (a *Analyzer) parseTargetURL(targetURL string) (*url.URL, error) {
 	parsed, err := url.ParseRequestURI(targetURL)
 	if err != nil {
-		logInfo("analyze.invalid_url", slog.String("url", targetURL))
+		a.logInfo("analyze.invalid_url", slog.String("url", targetURL))
 		return nil, appErr.NewValidationError("invalid URL format", err.Error())
 	}
-	if parsed.Scheme != "http" && parsed.Scheme != "https" {
-		logInfo("analyze.invalid_url", slog.String("url", targetURL))
-		return nil, appErr.NewValidationError("URL must use http or https scheme", fmt.Sprintf("got scheme: %s", parsed.Scheme))
+	if parsed.Scheme == "" {
+		a.logInfo("analyze.invalid_url", slog.String("url", targetURL))
+		return nil, appErr.NewValidationError("URL must be absolute", fmt.Sprintf("got: %s", targetURL))
 	}
-	logInfo("analyze.url_parsed", slog.String("host", parsed.Host))
+	a.logInfo("analyze.url_parsed", slog.String("host", parsed.Host))
 	return parsed, nil
 }
 
-// buildGetRequest constructs a GET request with the appropriate headers.
-func buildGetRequest(ctx context.Context, targetURL string) (*http.Request, error) {
-	logInfo("http.fetch", slog.String("url", targetURL))
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, targetURL, nil)
-	if err != nil {
-		logError("http.request_build_failed", slog.String("url", targetURL), slog.String("error", err.Error()))
-		return nil, appErr.NewInternalError("failed to create HTTP request", err)
+// checkHTTPURL rejects URLs that are not http(s) or lack a host.
+func checkHTTPURL(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return appErr.NewValidationError("URL must use http or https scheme", fmt.Sprintf("got scheme: %s", u.Scheme))
+	}
+	if u.Host == "" {
+		return appErr.NewValidationError("URL must have a host", fmt.Sprintf("got: %s", u.String()))
 	}
-	req.Header.Set("User-Agent", factory.UserAgent)
-	return req, nil
+	return nil
 }
 
-// fetchAndParseHTML executes the request and parses the response body as HTML.
-func fetchAndParseHTML(client *http.Client, req *http.Request) (*html.Node, error) {
-	resp, err := client.Do(req)
-	if err != nil {
-		if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
-			logError("http.timeout", slog.String("url", req.URL.String()))
-			return nil, appErr.NewTimeoutError(fmt.Sprintf("request to %s", req.URL.String()), err)
-		}
-		var netErr net.Error
-		if errors.As(err, &netErr) && netErr.Timeout() {
-			logError("http.timeout", slog.String("url", req.URL.String()))
-			return nil, appErr.NewTimeoutError(fmt.Sprintf("request to %s", req.URL.String()), err)
+// fetchedPage is the parsed document together with what was learned while fetching it.
+type fetchedPage struct {
+	Doc      *html.Node
+	Encoding model.EncodingInfo
+	Fetch    model.FetchInfo
+	Header   http.Header
+}
+
+// parseFetched checks that the fetched body is HTML, transcodes it to UTF-8
+// and parses it.
+func (a *Analyzer) parseFetched(resp *FetchResponse) (*fetchedPage, error) {
+	body := resp.Body
+	contentType := resp.Header.Get("Content-Type")
+	declared, sniffed, isHTML := util.DetectMediaType(contentType, body)
+	if !isHTML {
+		mediaType := declared
+		if mediaType == "" {
+			mediaType = sniffed
 		}
-		logError("http.error", slog.String("url", req.URL.String()), slog.String("error", ErrUnreachable.Error()))
-		return nil, appErr.WrapError(err, appErr.ErrorTypeUnavailable, fmt.Sprintf("URL %s is unreachable", req.URL.String()))
+		a.logError("http.unsupported_media_type", slog.String("declared", declared), slog.String("sniffed", sniffed))
+		return nil, appErr.NewUnsupportedMediaTypeError(mediaType, fmt.Sprintf("declared: %q, detected: %q", declared, sniffed))
 	}
-	defer resp.Body.Close()
 
-	logInfo("http.response", slog.Int("status", resp.StatusCode), slog.String("status_text", resp.Status))
-	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
-		logError("http.non_2xx", slog.Int("status", resp.StatusCode), slog.String("status_text", resp.Status))
-		return nil, appErr.NewUpstreamError(req.URL.Host, resp.StatusCode, fmt.Errorf("received status: %s", resp.Status))
-	}
+	decoded, encoding := util.DecodeHTML(body, contentType)
+	a.logInfo("html.charset", slog.String("charset", encoding.Charset), slog.String("source", encoding.Source))
 
-	logInfo("html.parse.start")
-	limitedBody := io.LimitReader(resp.Body, 2<<20) // 2MB cap
-	doc, parseErr := html.Parse(limitedBody)
+	a.logInfo("html.parse.start")
+	doc, parseErr := html.Parse(bytes.NewReader(decoded))
 	if parseErr != nil {
-		logError("html.parse.error", slog.String("error", ErrParseHTML.Error()))
+		a.logError("html.parse.error", slog.String("error", ErrParseHTML.Error()))
 		return nil, appErr.NewParseError("HTML", parseErr)
 	}
-	logInfo("html.parse.ok")
-	return doc, nil
+	a.logInfo("html.parse.ok")
+	return &fetchedPage{Doc: doc, Encoding: encoding, Fetch: resp.Info, Header: resp.Header}, nil
 }
 
-// strategiesFor builds the list of analysis strategies with dependencies injected.
-func strategiesFor(client *http.Client) []AnalyzerStrategy {
-	return []AnalyzerStrategy{
-		&HTMLVersionStrategy{},
-		&TitleStrategy{},
-		&HeadingsStrategy{},
-		&LinksStrategy{LinkChecker: &factory.DefaultLinkChecker{Client: client}},
-		&LoginFormStrategy{},
-	}
+// namedStrategy pairs a strategy with the name it is reported under and the
+// budget it runs within; a zero budget means only the request timeout applies.
+type namedStrategy struct {
+	name     string
+	strategy ContextStrategy
+	budget   time.Duration
 }
 
-// runStrategiesParallel executes all strategies concurrently, merging results.
-// The provided context is used to cancel in-flight strategy work if any strategy fails.
-func runStrategiesParallel(ctx context.Context, doc *html.Node, base *url.URL, strategies []AnalyzerStrategy) (*model.AnalyzeResult, error) {
+// runStrategiesParallel executes all strategies concurrently, merging the
+// results of those that succeed and recording a status for each. Each strategy
+// runs within its own budget; one that runs out is reported as timed_out and
+// whatever it produced so far is kept. In strict mode the first failure
+// cancels the others and is returned as an error; otherwise failures become
+// warnings on an otherwise complete result.
+func (a *Analyzer) runStrategiesParallel(ctx context.Context, doc *html.Node, base *url.URL, strategies []namedStrategy, strict bool) (*model.AnalyzeResult, error) {
 	result := &model.AnalyzeResult{}
+	statuses := make([]model.StrategyStatus, len(strategies))
 	var mu sync.Mutex
-	group, ctx := errgroup.WithContext(ctx)
+
+	group := &errgroup.Group{}
+	if strict {
+		group, ctx = errgroup.WithContext(ctx)
+	}
 
 	var slowestName string
 	var slowestDur time.Duration
 
-	logInfo("strategies.start", slog.Int("count", len(strategies)))
-	for _, s := range strategies {
-		strategy := s
+	a.logInfo("strategies.start", slog.Int("count", len(strategies)), slog.Bool("strict", strict))
+	for i, s := range strategies {
+		named := s
 		group.Go(func() error {
 			partial := &model.AnalyzeResult{}
-			strategyType := fmt.Sprintf("%T", strategy)
+			strategyType := strategyTypeName(named.strategy)
 			st := time.Now()
-			logInfo("strategy.start", slog.String("type", strategyType))
-			// Strategy interface does not take context; honor cancellation best-effort by early return
-			select {
-			case <-ctx.Done():
-				return ctx.Err()
-			default:
+			a.logInfo("strategy.start", slog.String("type", strategyType))
+			err := runWithBudget(ctx, named, doc, base, partial)
+			d := time.Since(st)
+			statuses[i] = model.StrategyStatus{Name: named.name, Status: model.StrategyOK, DurationMs: d.Milliseconds()}
+			if err != nil {
+				statuses[i].Status = strategyErrorStatus(err)
+				statuses[i].Error = err.Error()
 			}
-			if err := strategy.Analyze(doc, base, partial); err != nil {
-				logError("strategy.error", slog.String("type", strategyType), slog.String("error", err.Error()))
-				return err
+			a.metrics.ObserveStrategyDuration(strategyType, statuses[i].Status, d)
+			if err != nil {
+				a.logError("strategy.error", slog.String("type", strategyType), slog.String("status", statuses[i].Status), slog.String("error", err.Error()))
+				if statuses[i].Status != model.StrategyTimedOut || strict {
+					return err
+				}
 			}
-			d := time.Since(st)
-			metrics.ObserveStrategyDuration(strategyType, d)
 			mu.Lock()
 			mergeAnalyzeResult(result, partial)
 			if d > slowestDur {
@@ -174,7 +151,7 @@ func runStrategiesParallel(ctx context.Context, doc *html.Node, base *url.URL, s
 				slowestName = strategyType
 			}
 			mu.Unlock()
-			logInfo("strategy.done", slog.String("type", strategyType),
+			a.logInfo("strategy.done", slog.String("type", strategyType),
 				slog.String("html_version", partial.HTMLVersion),
 				slog.String("title", partial.Title),
 				slog.Bool("login_form", partial.LoginForm),
@@ -187,10 +164,36 @@ func runStrategiesParallel(ctx context.Context, doc *html.Node, base *url.URL, s
 		})
 	}
 
-	if err := group.Wait(); err != nil {
-		logError("analyze.error", slog.String("error", "strategy error"))
+	// Without strict mode the group never cancels, so Wait only reports the
+	// first error after every strategy has finished; the statuses carry the rest.
+	if err := group.Wait(); err != nil && strict {
+		a.logError("analyze.error", slog.String("error", "strategy error"))
 		return nil, appErr.WrapError(err, appErr.ErrorTypeInternal, "analysis strategy failed")
 	}
-	logInfo("strategies.done", slog.String("slowest_strategy", slowestName), slog.Int64("slowest_ms", slowestDur.Milliseconds()))
+	result.Strategies = statuses
+	for _, st := range statuses {
+		if st.Status != model.StrategyOK {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("strategy %s %s: %s", st.Name, st.Status, st.Error))
+		}
+	}
+	a.logInfo("strategies.done", slog.String("slowest_strategy", slowestName), slog.Int64("slowest_ms", slowestDur.Milliseconds()), slog.Int("warnings", len(result.Warnings)))
 	return result, nil
 }
+
+// runWithBudget runs the strategy under its budget, if any.
+func runWithBudget(ctx context.Context, named namedStrategy, doc *html.Node, base *url.URL, result *model.AnalyzeResult) error {
+	if named.budget > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, named.budget)
+		defer cancel()
+	}
+	return named.strategy.AnalyzeContext(ctx, doc, base, result)
+}
+
+// strategyErrorStatus maps a strategy error to the status reported for it.
+func strategyErrorStatus(err error) string {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return model.StrategyTimedOut
+	}
+	return model.StrategyFailed
+}
@@ -8,12 +8,26 @@ import (
 	"net/url"
 	"strings"
 	"testing"
+	appErr "web-analyzer-go/internal/errors"
 	"web-analyzer-go/internal/factory"
 	"web-analyzer-go/internal/model"
+	"web-analyzer-go/internal/util"
 
 	"golang.org/x/net/html"
 )
 
+var defaultMaxBodyBytes = DefaultLimits().DefaultMaxBodyBytes
+
+// loopbackGuard lets tests reach httptest servers, which the default dial guard blocks.
+var loopbackGuard, _ = factory.NewDialGuard(factory.DialGuardConfig{AllowCIDRs: []string{"127.0.0.0/8", "::1"}})
+
+var (
+	testClient       = (&factory.DefaultHTTPClientFactory{Guard: loopbackGuard}).NewClient()
+	testAnalyzer     = New(WithHTTPClient(testClient))
+	testFetcher      = &HTTPFetcher{Client: testClient}
+	testFetchOptions = FetchOptions{UserAgent: factory.UserAgent, MaxBodyBytes: defaultMaxBodyBytes}
+)
+
 func Test_parseTargetURL(t *testing.T) {
 	cases := []struct {
 		name string
@@ -22,12 +36,13 @@ func Test_parseTargetURL(t *testing.T) {
 	}{
 		{"http", "http://simplewebapp.com", true},
 		{"https", "https://simplewebapp.com", true},
-		{"invalid scheme", "ftp://simplewebapp.com", false},
+		{"other scheme left to the fetcher", "file:///tmp/page.html", true},
+		{"relative", "/page.html", false},
 		{"garbage", "://", false},
 	}
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
-			u, err := parseTargetURL(tc.url)
+			u, err := testAnalyzer.parseTargetURL(tc.url)
 			if tc.ok && err != nil {
 				t.Fatalf("expected ok, got error: %v", err)
 			}
@@ -38,8 +53,29 @@ func Test_parseTargetURL(t *testing.T) {
 	}
 }
 
+func Test_HTTPFetcher_rejectsOtherSchemes(t *testing.T) {
+	for _, u := range []string{"ftp://simplewebapp.com/", "file:///tmp/page.html"} {
+		_, err := testFetcher.Fetch(context.Background(), u, testFetchOptions)
+		ae, ok := appErr.GetAppError(err)
+		if !ok || ae.Type != appErr.ErrorTypeValidation {
+			t.Errorf("%s: expected VALIDATION_ERROR, got %v", u, err)
+		}
+	}
+}
+
+func Test_AnalyzePage_fetcherServesOtherSchemes(t *testing.T) {
+	a := New(WithFetcher(fixtureFetcher{"file:///tmp/page.html": `<html><head><title>Local</title></head></html>`}))
+	res, err := a.AnalyzePage(context.Background(), "file:///tmp/page.html", Options{LinkCheck: LinkCheckNone})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Title != "Local" {
+		t.Fatalf("unexpected title: %q", res.Title)
+	}
+}
+
 func Test_buildGetRequest_setsUserAgent(t *testing.T) {
-	req, err := buildGetRequest(context.Background(), "http://simplewebapp.com")
+	req, err := testFetcher.buildGetRequest(context.Background(), "http://simplewebapp.com", factory.UserAgent)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -48,16 +84,14 @@ func Test_buildGetRequest_setsUserAgent(t *testing.T) {
 	}
 }
 
-func Test_fetchAndParseHTML_non2xx(t *testing.T) {
+func Test_HTTPFetcher_non2xx(t *testing.T) {
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusBadRequest)
 		_, _ = w.Write([]byte("bad"))
 	}))
 	defer srv.Close()
 
-	client := (&factory.DefaultHTTPClientFactory{}).NewClient()
-	req, _ := buildGetRequest(context.Background(), srv.URL)
-	_, err := fetchAndParseHTML(client, req)
+	_, err := testFetcher.Fetch(context.Background(), srv.URL, testFetchOptions)
 	if err == nil {
 		t.Fatalf("expected error for non-2xx status")
 	}
@@ -83,10 +117,19 @@ func (s *strategyLogin) Analyze(_ *html.Node, _ *url.URL, r *model.AnalyzeResult
 	return nil
 }
 
+// named adapts legacy strategies and names them after their position.
+func named(strategies ...AnalyzerStrategy) []namedStrategy {
+	out := make([]namedStrategy, len(strategies))
+	for i, s := range strategies {
+		out[i] = namedStrategy{name: fmt.Sprintf("s%d", i), strategy: AdaptStrategy(s)}
+	}
+	return out
+}
+
 func Test_runStrategiesParallel_merge(t *testing.T) {
 	doc, _ := html.Parse(strings.NewReader("<!DOCTYPE html><html><head><title>X</title></head><body></body></html>"))
 	base, _ := url.Parse("http://simplewebapp.com")
-	res, err := runStrategiesParallel(context.Background(), doc, base, []AnalyzerStrategy{&strategyOK{}, &strategyLogin{}})
+	res, err := testAnalyzer.runStrategiesParallel(context.Background(), doc, base, named(&strategyOK{}, &strategyLogin{}), false)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -98,12 +141,30 @@ func Test_runStrategiesParallel_merge(t *testing.T) {
 func Test_runStrategiesParallel_error(t *testing.T) {
 	doc, _ := html.Parse(strings.NewReader("<!DOCTYPE html><html><body></body></html>"))
 	base, _ := url.Parse("http://simplewebapp.com")
-	_, err := runStrategiesParallel(context.Background(), doc, base, []AnalyzerStrategy{&strategyOK{}, &strategyErr{}})
+	_, err := testAnalyzer.runStrategiesParallel(context.Background(), doc, base, named(&strategyOK{}, &strategyErr{}), true)
 	if err == nil {
 		t.Fatalf("expected error from failing strategy")
 	}
 }
 
+func Test_runStrategiesParallel_partial(t *testing.T) {
+	doc, _ := html.Parse(strings.NewReader("<!DOCTYPE html><html><body></body></html>"))
+	base, _ := url.Parse("http://simplewebapp.com")
+	res, err := testAnalyzer.runStrategiesParallel(context.Background(), doc, base, named(&strategyOK{}, &strategyErr{}, &strategyLogin{}), false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Title != "ok" || !res.LoginForm {
+		t.Fatalf("expected results from healthy strategies, got %+v", res)
+	}
+	if res.Strategies[0].Status != model.StrategyOK || res.Strategies[1].Status != model.StrategyFailed || res.Strategies[1].Error != "boom" {
+		t.Fatalf("unexpected statuses: %+v", res.Strategies)
+	}
+	if len(res.Warnings) != 1 {
+		t.Fatalf("expected one warning, got %v", res.Warnings)
+	}
+}
+
 func Test_AnalyzePage_integration_success(t *testing.T) {
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "text/html")
@@ -112,7 +173,7 @@ func Test_AnalyzePage_integration_success(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	res, err := AnalyzePage(context.Background(), srv.URL)
+	res, err := testAnalyzer.AnalyzePage(context.Background(), srv.URL, Options{})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -136,7 +197,7 @@ func Test_DefaultLinkChecker_HEAD_200(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	checker := &factory.DefaultLinkChecker{Client: (&factory.DefaultHTTPClientFactory{}).NewClient()}
+	checker := &factory.DefaultLinkChecker{Client: testClient}
 	if ok := checker.IsAccessible(srv.URL); !ok {
 		t.Fatalf("expected accessible for HEAD 200")
 	}
@@ -155,7 +216,7 @@ func Test_DefaultLinkChecker_HEAD_405_GET_200(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	checker := &factory.DefaultLinkChecker{Client: (&factory.DefaultHTTPClientFactory{}).NewClient()}
+	checker := &factory.DefaultLinkChecker{Client: testClient}
 	if ok := checker.IsAccessible(srv.URL); !ok {
 		t.Fatalf("expected accessible for HEAD 405 then GET 200")
 	}
@@ -174,7 +235,7 @@ func Test_DefaultLinkChecker_HEAD_405_GET_404(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	checker := &factory.DefaultLinkChecker{Client: (&factory.DefaultHTTPClientFactory{}).NewClient()}
+	checker := &factory.DefaultLinkChecker{Client: testClient}
 	if ok := checker.IsAccessible(srv.URL); ok {
 		t.Fatalf("expected inaccessible for HEAD 405 then GET 404")
 	}
@@ -190,8 +251,168 @@ func Test_DefaultLinkChecker_HEAD_404(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	checker := &factory.DefaultLinkChecker{Client: (&factory.DefaultHTTPClientFactory{}).NewClient()}
+	checker := &factory.DefaultLinkChecker{Client: testClient}
 	if ok := checker.IsAccessible(srv.URL); ok {
 		t.Fatalf("expected inaccessible for HEAD 404")
 	}
 }
+
+// "Привет" encoded as windows-1251
+var cp1251Privet = "\xcf\xf0\xe8\xe2\xe5\xf2"
+
+func Test_parseFetched_charsetFromHeader(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=windows-1251")
+		_, _ = w.Write([]byte("<html><head><title>" + cp1251Privet + "</title></head></html>"))
+	}))
+	defer srv.Close()
+
+	resp, err := testFetcher.Fetch(context.Background(), srv.URL, testFetchOptions)
+	if err != nil {
+		t.Fatalf("unexpected fetch error: %v", err)
+	}
+	page, err := testAnalyzer.parseFetched(resp)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := util.ExtractTitle(page.Doc); got != "Привет" {
+		t.Fatalf("unexpected title: %q", got)
+	}
+	if page.Encoding.Charset != "windows-1251" || page.Encoding.Source != util.CharsetSourceHeader {
+		t.Fatalf("unexpected encoding: %+v", page.Encoding)
+	}
+}
+
+func Test_parseFetched_charsetFromMeta(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte(`<html><head><meta charset="windows-1251"><title>` + cp1251Privet + "</title></head></html>"))
+	}))
+	defer srv.Close()
+
+	resp, err := testFetcher.Fetch(context.Background(), srv.URL, testFetchOptions)
+	if err != nil {
+		t.Fatalf("unexpected fetch error: %v", err)
+	}
+	page, err := testAnalyzer.parseFetched(resp)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := util.ExtractTitle(page.Doc); got != "Привет" {
+		t.Fatalf("unexpected title: %q", got)
+	}
+	if page.Encoding.Source != util.CharsetSourceMeta {
+		t.Fatalf("expected meta source, got %+v", page.Encoding)
+	}
+}
+
+func Test_parseFetched_mediaTypes(t *testing.T) {
+	cases := []struct {
+		name        string
+		contentType string
+		body        string
+		ok          bool
+	}{
+		{"html", "text/html; charset=utf-8", "<html><title>x</title></html>", true},
+		{"xhtml", "application/xhtml+xml", `<?xml version="1.0"?><html xmlns="http://www.w3.org/1999/xhtml"><title>x</title></html>`, true},
+		{"wrong header", "application/octet-stream", "<!DOCTYPE html><html><title>x</title></html>", true},
+		{"pdf", "application/pdf", "%PDF-1.7\n", false},
+		{"pdf labelled html", "text/html", "%PDF-1.7\n", false},
+		{"json", "application/json", `{"a":1}`, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tc.contentType)
+				_, _ = w.Write([]byte(tc.body))
+			}))
+			defer srv.Close()
+
+			resp, err := testFetcher.Fetch(context.Background(), srv.URL, testFetchOptions)
+			if err != nil {
+				t.Fatalf("unexpected fetch error: %v", err)
+			}
+			_, err = testAnalyzer.parseFetched(resp)
+			if tc.ok && err != nil {
+				t.Fatalf("expected ok, got error: %v", err)
+			}
+			if !tc.ok {
+				ae, isApp := appErr.GetAppError(err)
+				if !isApp || ae.StatusCode != http.StatusUnsupportedMediaType {
+					t.Fatalf("expected 415 AppError, got %v", err)
+				}
+			}
+		})
+	}
+}
+
+func Test_HTTPFetcher_fetchInfo(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/start", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/moved", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/final", http.StatusFound)
+	})
+	mux.HandleFunc("/final", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Header().Set("Server", "test")
+		_, _ = w.Write([]byte("<html><title>x</title></html>"))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	resp, err := testFetcher.Fetch(context.Background(), srv.URL+"/start", testFetchOptions)
+	if err != nil {
+		t.Fatalf("unexpected fetch error: %v", err)
+	}
+	page, err := testAnalyzer.parseFetched(resp)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	f := page.Fetch
+	if f.FinalURL != srv.URL+"/final" || f.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected final url/status: %+v", f)
+	}
+	if len(f.Redirects) != 2 || f.Redirects[0].StatusCode != http.StatusMovedPermanently ||
+		f.Redirects[0].URL != srv.URL+"/start" || f.Redirects[1].Location != srv.URL+"/final" {
+		t.Fatalf("unexpected redirect chain: %+v", f.Redirects)
+	}
+	if f.Headers["server"] != "test" || f.Protocol != "HTTP/1.1" || f.Truncated {
+		t.Fatalf("unexpected fetch info: %+v", f)
+	}
+}
+
+func Test_HTTPFetcher_truncated(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte("<html><body>" + strings.Repeat("a", int(defaultMaxBodyBytes)) + "</body></html>"))
+	}))
+	defer srv.Close()
+
+	resp, err := testFetcher.Fetch(context.Background(), srv.URL, testFetchOptions)
+	if err != nil {
+		t.Fatalf("unexpected fetch error: %v", err)
+	}
+	page, err := testAnalyzer.parseFetched(resp)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !page.Fetch.Truncated || page.Fetch.BytesRead != defaultMaxBodyBytes {
+		t.Fatalf("expected truncation at %d bytes, got %+v", defaultMaxBodyBytes, page.Fetch)
+	}
+}
+
+func Test_HTTPFetcher_blockedByDialGuard(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("request should not reach a loopback server")
+	}))
+	defer srv.Close()
+
+	strict := &HTTPFetcher{Client: (&factory.DefaultHTTPClientFactory{}).NewClient()}
+	_, err := strict.Fetch(context.Background(), srv.URL, testFetchOptions)
+	ae, ok := appErr.GetAppError(err)
+	if !ok || ae.StatusCode != http.StatusForbidden || ae.Type != appErr.ErrorTypeForbidden {
+		t.Fatalf("expected FORBIDDEN AppError, got %v", err)
+	}
+}
@@ -0,0 +1,282 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	appErr "web-analyzer-go/internal/errors"
+	"web-analyzer-go/internal/factory"
+	"web-analyzer-go/internal/metrics"
+	"web-analyzer-go/internal/model"
+	"web-analyzer-go/internal/util"
+)
+
+// Analyzer fetches pages and runs the registered strategies over them. All
+// collaborators are injected through AnalyzerOption values passed to New, so
+// a single process can host differently configured analyzers side by side.
+// The link cache, link scheduler and robots.txt cache are typically shared
+// process-wide, so their entries and limits span every analyzer given them.
+// An Analyzer is safe for concurrent use.
+type Analyzer struct {
+	fetcher     Fetcher
+	client      *http.Client
+	linkChecker factory.ContextLinkChecker
+	linkCache   *factory.LinkCache
+	scheduler   *factory.LinkScheduler
+	robots      *factory.RobotsCache
+	linkRetry   factory.RetryPolicy
+	tracking    []string
+	strategies  []StrategySpec
+	budgets     map[string]time.Duration
+	limits      Limits
+	logger      *slog.Logger
+	metrics     metrics.Sink
+}
+
+// AnalyzerOption configures an Analyzer built by New.
+type AnalyzerOption func(*Analyzer)
+
+// WithFetcher replaces the HTTP fetcher used to retrieve target pages.
+func WithFetcher(f Fetcher) AnalyzerOption {
+	return func(a *Analyzer) { a.fetcher = f }
+}
+
+// WithHTTPClient sets the client used by the default fetcher and link checker.
+func WithHTTPClient(c *http.Client) AnalyzerOption {
+	return func(a *Analyzer) { a.client = c }
+}
+
+// WithLinkChecker sets a shared link checker. Checkers without context
+// support are adapted with factory.AdaptLinkChecker. Without one, each analysis
+// gets a factory.DefaultLinkChecker that sends the request's User-Agent.
+func WithLinkChecker(c factory.LinkChecker) AnalyzerOption {
+	return func(a *Analyzer) { a.linkChecker = factory.AdaptLinkChecker(c) }
+}
+
+// WithLinkCache puts a link-check cache in front of whichever link checker an
+// analysis uses.
+func WithLinkCache(c *factory.LinkCache) AnalyzerOption {
+	return func(a *Analyzer) { a.linkCache = c }
+}
+
+// WithLinkScheduler routes link checks through a scheduler that enforces
+// global and per-host concurrency limits. Cache hits bypass the scheduler.
+func WithLinkScheduler(s *factory.LinkScheduler) AnalyzerOption {
+	return func(a *Analyzer) { a.scheduler = s }
+}
+
+// WithRobotsCache sets the robots.txt cache consulted by analyses whose
+// robots policy is not RobotsIgnore.
+func WithRobotsCache(c *factory.RobotsCache) AnalyzerOption {
+	return func(a *Analyzer) { a.robots = c }
+}
+
+// WithLinkRetry sets the retry policy of the default link checker. It has no
+// effect on a checker installed with WithLinkChecker, which also ignores the
+// head link-check mode.
+func WithLinkRetry(p factory.RetryPolicy) AnalyzerOption {
+	return func(a *Analyzer) { a.linkRetry = p }
+}
+
+// WithTrackingParams replaces the query parameters stripped from links before
+// they are deduplicated and checked; see util.URLNormalizer for the pattern
+// syntax. An empty non-nil list strips none.
+func WithTrackingParams(params []string) AnalyzerOption {
+	return func(a *Analyzer) { a.tracking = params }
+}
+
+// WithStrategies replaces the registered strategies.
+func WithStrategies(specs ...StrategySpec) AnalyzerOption {
+	return func(a *Analyzer) { a.strategies = specs }
+}
+
+// WithStrategyBudgets overrides the time budget of registered strategies by
+// name. A zero duration removes the strategy's budget so it may use the whole
+// request timeout.
+func WithStrategyBudgets(budgets map[string]time.Duration) AnalyzerOption {
+	return func(a *Analyzer) { a.budgets = budgets }
+}
+
+// WithLimits sets the defaults and ceilings applied to per-request Options.
+func WithLimits(l Limits) AnalyzerOption {
+	return func(a *Analyzer) { a.limits = l }
+}
+
+// WithLogger sets the logger; a "component" attribute is added to every record.
+func WithLogger(l *slog.Logger) AnalyzerOption {
+	return func(a *Analyzer) { a.logger = l }
+}
+
+// WithMetrics sets the sink that receives duration measurements and the
+// link-check cache and scheduler activity of this analyzer's analyses.
+func WithMetrics(m metrics.Sink) AnalyzerOption {
+	return func(a *Analyzer) { a.metrics = m }
+}
+
+// New builds an Analyzer. Unset collaborators default to the shared HTTP
+// client, an HTTPFetcher, DefaultStrategies, DefaultLimits, the process
+// logger and Prometheus metrics.
+func New(opts ...AnalyzerOption) *Analyzer {
+	a := &Analyzer{}
+	for _, opt := range opts {
+		opt(a)
+	}
+	if a.logger == nil {
+		a.logger = util.EnsureLogger()
+	}
+	a.logger = a.logger.With(slog.String("component", "analyzer"))
+	if a.client == nil {
+		a.client = (&factory.DefaultHTTPClientFactory{}).NewClient()
+	}
+	if a.fetcher == nil {
+		a.fetcher = &HTTPFetcher{Client: a.client, Logger: a.logger}
+	}
+	if a.strategies == nil {
+		a.strategies = DefaultStrategies()
+	}
+	if a.robots == nil {
+		a.robots = factory.NewRobotsCache(a.client, factory.DefaultRobotsConfig())
+	}
+	if a.tracking == nil {
+		a.tracking = util.DefaultTrackingParams
+	}
+	if a.limits == (Limits{}) {
+		a.limits = DefaultLimits()
+	}
+	if a.metrics == nil {
+		a.metrics = metrics.PrometheusSink{}
+	}
+	return a
+}
+
+// AnalyzePage fetches the target URL, parses its HTML, and runs a suite of
+// analysis strategies to produce a consolidated *model.AnalyzeResult. It
+// respects the provided context for request-level timeouts and cancellation.
+// Zero-valued opts fall back to the analyzer's limits.
+func (a *Analyzer) AnalyzePage(ctx context.Context, targetURL string, opts Options) (*model.AnalyzeResult, error) {
+	a.logInfo("analyze.start", slog.String("url", targetURL))
+	start := time.Now()
+
+	parsed, err := a.parseTargetURL(targetURL)
+	if err != nil {
+		return nil, err
+	}
+
+	opts, err = opts.resolve(a.limits, a.strategyNames())
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	var robotsWarning string
+	if opts.Robots != RobotsIgnore && !a.robots.Allowed(ctx, targetURL) {
+		a.logInfo("analyze.robots_disallowed", slog.String("url", targetURL), slog.String("policy", opts.Robots))
+		if opts.Robots == RobotsObey {
+			return nil, appErr.NewForbiddenError("robots.txt disallows fetching this page", fmt.Errorf("user-agent %s: %s", factory.RobotsAgent, targetURL))
+		}
+		robotsWarning = fmt.Sprintf("robots.txt disallows this page for %s", factory.RobotsAgent)
+	}
+
+	resp, err := a.fetcher.Fetch(ctx, targetURL, FetchOptions{UserAgent: opts.UserAgent, MaxBodyBytes: opts.MaxBodyBytes})
+	if err != nil {
+		return nil, err
+	}
+	page, err := a.parseFetched(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	// Links are resolved and classified against where the page actually
+	// lives, which after redirects may be another host.
+	pageURL := parsed
+	if final, err := url.Parse(page.Fetch.FinalURL); err == nil && final.IsAbs() {
+		pageURL = final
+	}
+	strategies, skipped := a.strategiesFor(opts, page)
+	result, err := a.runStrategiesParallel(ctx, page.Doc, pageURL, strategies, opts.Strict)
+	if err != nil {
+		return nil, err
+	}
+	result.Strategies = append(result.Strategies, skipped...)
+	if opts.wants(StrategyLinks) {
+		result.LinkCheckMode = opts.LinkCheck
+	}
+	if robotsWarning != "" {
+		result.Warnings = append(result.Warnings, robotsWarning)
+	}
+	result.Encoding = page.Encoding
+	result.Fetch = page.Fetch
+
+	a.logAnalyzeDone(result, start)
+	return result, nil
+}
+
+// strategiesFor builds the registered strategies selected by opts with
+// dependencies injected, and reports the unselected ones as skipped. page is
+// the fetched document, or nil.
+func (a *Analyzer) strategiesFor(opts Options, page *fetchedPage) ([]namedStrategy, []model.StrategyStatus) {
+	deps := StrategyDeps{Normalizer: util.URLNormalizer{TrackingParams: a.tracking, SortQuery: opts.SortQuery}}
+	if page != nil {
+		deps.Page, deps.Header = &page.Fetch, page.Header
+	}
+	if opts.LinkCheck != LinkCheckNone {
+		headOnly := opts.LinkCheck == LinkCheckHead
+		checker := a.linkChecker
+		if checker == nil {
+			checker = &factory.DefaultLinkChecker{Client: a.client, UserAgent: opts.UserAgent, Retry: a.linkRetry, HeadOnly: headOnly}
+		}
+		namespace := ""
+		if headOnly {
+			namespace = LinkCheckHead
+		}
+		deps.LinkChecker = a.linkCache.WrapNamespace(a.scheduler.Wrap(checker, a.metrics), namespace, opts.UserAgent, a.metrics)
+		if opts.Robots != RobotsIgnore {
+			deps.LinkChecker = a.robots.Wrap(deps.LinkChecker, opts.Robots == RobotsObey)
+		}
+		gate := &factory.FetchGate{Scheduler: a.scheduler, Robots: a.robots, Obey: opts.Robots == RobotsObey, Metrics: a.metrics}
+		deps.Fetcher = gatedFetcher{next: a.fetcher, gate: gate}
+		if opts.Soft404 {
+			deps.Soft404 = &factory.Soft404Detector{Client: a.client, UserAgent: opts.UserAgent, MaxBodyBytes: opts.MaxBodyBytes, Gate: gate}
+		}
+	}
+	var strategies []namedStrategy
+	var skipped []model.StrategyStatus
+	for _, spec := range a.strategies {
+		if !opts.wants(spec.Name) {
+			skipped = append(skipped, model.StrategyStatus{Name: spec.Name, Status: model.StrategySkipped})
+			continue
+		}
+		budget := spec.Budget
+		if b, ok := a.budgets[spec.Name]; ok {
+			budget = b
+		}
+		strategies = append(strategies, namedStrategy{name: spec.Name, strategy: spec.New(opts, deps), budget: budget})
+	}
+	return strategies, skipped
+}
+
+func (a *Analyzer) strategyNames() []string {
+	names := make([]string, len(a.strategies))
+	for i, spec := range a.strategies {
+		names[i] = spec.Name
+	}
+	return names
+}
+
+var defaultAnalyzer = sync.OnceValue(func() *Analyzer { return New() })
+
+// AnalyzePage runs AnalyzePage on a default Analyzer built with New().
+func AnalyzePage(ctx context.Context, targetURL string, opts Options) (*model.AnalyzeResult, error) {
+	return defaultAnalyzer().AnalyzePage(ctx, targetURL, opts)
+}
+
+// AnalyzeHTML runs AnalyzeHTML on a default Analyzer built with New().
+func AnalyzeHTML(ctx context.Context, body []byte, contentType, baseURL string, opts Options) (*model.AnalyzeResult, error) {
+	return defaultAnalyzer().AnalyzeHTML(ctx, body, contentType, baseURL, opts)
+}
@@ -1,9 +1,19 @@
 package analyzer
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
 	"strings"
+	"sync"
 	"testing"
+	"time"
+	"web-analyzer-go/internal/factory"
+	"web-analyzer-go/internal/model"
 	"web-analyzer-go/internal/util"
 
 	"golang.org/x/net/html"
@@ -47,6 +57,18 @@ func TestExtractTitle(t *testing.T) {
 	}
 }
 
+func TestDecodeHTML_truncatedUTF8(t *testing.T) {
+	body := []byte("<p>Привет")
+	// Cut the final two-byte letter in half, as the body size cap may.
+	decoded, enc := util.DecodeHTML(body[:len(body)-1], "text/html")
+	if enc.Charset != "utf-8" || string(decoded) != "<p>Приве" {
+		t.Fatalf("expected UTF-8 without the cut letter, got %q as %+v", decoded, enc)
+	}
+	if _, enc := util.DecodeHTML([]byte("caf\xe9 au lait"), "text/html"); enc.Charset != "windows-1252" {
+		t.Fatalf("expected windows-1252 fallback, got %+v", enc)
+	}
+}
+
 func TestCountHeadings(t *testing.T) {
 	h := `<!DOCTYPE html><html><body><h1>H1</h1><h2>H2</h2><h2>H2b</h2></body></html>`
 	doc, _ := html.Parse(strings.NewReader(h))
@@ -88,3 +110,564 @@ func TestCountLinks(t *testing.T) {
 		t.Errorf("expected 1 internal and 1 external, got %d internal, %d external", internal, external)
 	}
 }
+
+// fixtureFetcher serves in-memory documents keyed by URL.
+type fixtureFetcher map[string]string
+
+func (f fixtureFetcher) Fetch(_ context.Context, targetURL string, _ FetchOptions) (*FetchResponse, error) {
+	body, ok := f[targetURL]
+	if !ok {
+		return nil, ErrUnreachable
+	}
+	return &FetchResponse{
+		Body:     []byte(body),
+		Header:   http.Header{"Content-Type": []string{"text/html"}},
+		FinalURL: targetURL,
+	}, nil
+}
+
+type recordingSink struct {
+	mu           sync.Mutex
+	strategies   []string
+	totals       int
+	cacheLookups int
+	queued       int
+}
+
+func (s *recordingSink) ObserveStrategyDuration(strategy, _ string, _ time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.strategies = append(s.strategies, strategy)
+}
+
+func (s *recordingSink) ObserveAnalyzeTotalDuration(time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.totals++
+}
+
+func (s *recordingSink) ObserveLinkCacheLookup(bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cacheLookups++
+}
+
+func (s *recordingSink) AddLinkCheckQueueDepth(delta int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if delta > 0 {
+		s.queued += delta
+	}
+}
+
+func TestAnalyzer_injectedDependencies(t *testing.T) {
+	sink := &recordingSink{}
+	a := New(
+		WithFetcher(fixtureFetcher{"http://fixture.test/": `<html><head><title>Fixture</title></head><body><a href="/x">x</a></body></html>`}),
+		WithLinkChecker(&mockChecker{}),
+		WithStrategies(
+			StrategySpec{Name: "title", New: func(Options, StrategyDeps) ContextStrategy { return AdaptStrategy(&TitleStrategy{}) }},
+			StrategySpec{Name: "links", New: func(_ Options, d StrategyDeps) ContextStrategy { return &LinksStrategy{LinkChecker: d.LinkChecker} }},
+		),
+		WithLinkCache(factory.NewLinkCache(factory.DefaultLinkCacheConfig())),
+		WithLinkScheduler(factory.NewLinkScheduler(factory.DefaultLinkSchedulerConfig())),
+		WithMetrics(sink),
+	)
+
+	res, err := a.AnalyzePage(context.Background(), "http://fixture.test/", Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Title != "Fixture" || res.Links.Internal != 1 || res.HTMLVersion != "" {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	if len(sink.strategies) != 2 || sink.totals != 1 || sink.cacheLookups != 1 || sink.queued != 1 {
+		t.Fatalf("unexpected metrics: %+v", sink)
+	}
+
+	if _, err := a.AnalyzePage(context.Background(), "http://fixture.test/", Options{Strategies: []string{StrategyHeadings}}); err == nil {
+		t.Fatalf("expected unregistered strategy to be rejected")
+	}
+}
+
+// deadlineFetcher records the deadline of the context it fetches with.
+type deadlineFetcher struct {
+	fixtureFetcher
+	deadline time.Time
+}
+
+func (f *deadlineFetcher) Fetch(ctx context.Context, targetURL string, opts FetchOptions) (*FetchResponse, error) {
+	f.deadline, _ = ctx.Deadline()
+	return f.fixtureFetcher.Fetch(ctx, targetURL, opts)
+}
+
+func TestAnalyzePage_honorsTimeoutOption(t *testing.T) {
+	if c := (&factory.DefaultHTTPClientFactory{}).NewClient(); c.Timeout != 0 {
+		t.Fatalf("shared client must leave the deadline to the request context, has Timeout %s", c.Timeout)
+	}
+	f := &deadlineFetcher{fixtureFetcher: fixtureFetcher{"http://fixture.test/": `<title>Fixture</title>`}}
+	a := New(WithFetcher(f))
+	start := time.Now()
+	if _, err := a.AnalyzePage(context.Background(), "http://fixture.test/", Options{Timeout: 20 * time.Second, LinkCheck: LinkCheckNone}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := f.deadline.Sub(start); got < 19*time.Second || got > 21*time.Second {
+		t.Fatalf("expected a 20s deadline, got %s", got)
+	}
+}
+
+// blockingChecker holds every check until its context is cancelled.
+type blockingChecker struct{}
+
+func (blockingChecker) IsAccessibleContext(ctx context.Context, _ string) bool {
+	<-ctx.Done()
+	return false
+}
+
+func TestLinksStrategy_stopsOnCancel(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("<html><body>")
+	for i := 0; i < 100; i++ {
+		fmt.Fprintf(&b, `<a href="/p%d">p</a>`, i)
+	}
+	b.WriteString("</body></html>")
+	doc, _ := html.Parse(strings.NewReader(b.String()))
+	base, _ := url.Parse("http://localhost")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := (&LinksStrategy{LinkChecker: blockingChecker{}}).AnalyzeContext(ctx, doc, base, &model.AnalyzeResult{})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Fatalf("link checks did not stop promptly: %s", d)
+	}
+}
+
+// slowPathChecker accepts links immediately unless their path starts with
+// /slow, which block until the context is cancelled.
+type slowPathChecker struct{}
+
+func (c slowPathChecker) IsAccessible(link string) bool {
+	return c.IsAccessibleContext(context.Background(), link)
+}
+
+func (slowPathChecker) IsAccessibleContext(ctx context.Context, link string) bool {
+	if strings.Contains(link, "/slow") {
+		<-ctx.Done()
+		return false
+	}
+	return true
+}
+
+func TestAnalyzer_strategyBudgetKeepsPartialResult(t *testing.T) {
+	a := New(
+		WithFetcher(fixtureFetcher{"http://fixture.test/": `<html><head><title>Budget</title></head><body>
+			<a href="/fast1">a</a><a href="/fast2">b</a><a href="/slow">c</a></body></html>`}),
+		WithLinkChecker(slowPathChecker{}),
+		WithStrategyBudgets(map[string]time.Duration{StrategyLinks: 50 * time.Millisecond}),
+		WithMetrics(&recordingSink{}),
+	)
+
+	res, err := a.AnalyzePage(context.Background(), "http://fixture.test/", Options{Strategies: []string{StrategyTitle, StrategyLinks}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Title != "Budget" {
+		t.Fatalf("title strategy should be unaffected, got %q", res.Title)
+	}
+	if res.Links.Internal != 3 || res.Links.Unchecked != 1 || res.Links.Inaccessible != 0 {
+		t.Fatalf("expected partial link stats, got %+v", res.Links)
+	}
+	for _, st := range res.Strategies {
+		if st.Name == StrategyLinks && st.Status != model.StrategyTimedOut {
+			t.Fatalf("expected links to be timed_out, got %+v", st)
+		}
+	}
+
+	if _, err := a.AnalyzePage(context.Background(), "http://fixture.test/", Options{Strategies: []string{StrategyLinks}, Strict: true}); err == nil {
+		t.Fatalf("expected strict mode to fail on a timed out strategy")
+	}
+}
+
+func TestLinksStrategy_detailedReport(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/old", func(w http.ResponseWriter, r *http.Request) { http.Redirect(w, r, "/ok", http.StatusMovedPermanently) })
+	mux.HandleFunc("/missing", http.NotFound)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	h := `<html><body>
+	<a href="/ok">  Home
+	  page </a>
+	<a href="/ok">Again</a>
+	<a href="/old"><img src="/logo.png" alt="Logo"></a>
+	<a href="/missing">Gone</a>
+	</body></html>`
+	doc, _ := html.Parse(strings.NewReader(h))
+	base, _ := url.Parse(srv.URL)
+	result := &model.AnalyzeResult{}
+	checker := &factory.DefaultLinkChecker{Client: srv.Client()}
+	if err := (&LinksStrategy{LinkChecker: checker}).AnalyzeContext(context.Background(), doc, base, result); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	byHref := map[string]model.LinkDetail{}
+	for _, l := range result.LinkDetails {
+		byHref[l.Href] = l
+	}
+	if len(result.LinkDetails) != 4 {
+		t.Fatalf("expected 4 unique links, got %+v", result.LinkDetails)
+	}
+	if ok := byHref["/ok"]; ok.Occurrences != 2 || ok.Text != "Home page" || ok.Element != "a" || ok.Attribute != "href" ||
+		!ok.Internal || !ok.Checked || !ok.Accessible || ok.StatusCode != http.StatusOK || ok.URL != srv.URL+"/ok" {
+		t.Fatalf("unexpected /ok detail: %+v", ok)
+	}
+	if old := byHref["/old"]; old.Text != "Logo" || old.RedirectURL != srv.URL+"/ok" || !old.Accessible {
+		t.Fatalf("unexpected /old detail: %+v", old)
+	}
+	if img := byHref["/logo.png"]; img.Kind != model.LinkKindImage || img.Element != "img" || img.Attribute != "src" || img.StatusCode != http.StatusNotFound {
+		t.Fatalf("unexpected /logo.png detail: %+v", img)
+	}
+	if missing := byHref["/missing"]; missing.Accessible || missing.StatusCode != http.StatusNotFound || missing.Error != "404 Not Found" {
+		t.Fatalf("unexpected /missing detail: %+v", missing)
+	}
+	wantAnchors := model.LinkStats{Internal: 3, Inaccessible: 1, Broken: 1,
+		Breakdown: model.LinkBreakdown{OK: 2, Redirect: 1, ClientError: 1}}
+	if want := wantAnchors; result.Links != want {
+		t.Fatalf("expected anchor stats %+v computed from the report, got %+v", want, result.Links)
+	}
+	if want := (model.LinkStats{Inaccessible: 1, Broken: 1, Breakdown: model.LinkBreakdown{ClientError: 1}}); result.LinkKinds[model.LinkKindImage] != want {
+		t.Fatalf("expected image stats %+v, got %+v", want, result.LinkKinds)
+	}
+}
+
+func TestExtractLinks_kinds(t *testing.T) {
+	h := `<html><head>
+	<link rel="stylesheet" href="/site.css">
+	<link rel="preload" href="/font.woff2">
+	<link rel="icon" href="/favicon.ico">
+	<script src="/app.js"></script>
+	</head><body>
+	<a href="/page">Page</a>
+	<map><area href="/area"></map>
+	<img src="/page">
+	<picture><source src="/hero.webp"></picture>
+	<video src="/clip.mp4"><source src="/clip.webm"></video>
+	<iframe src="https://embed.example/player"></iframe>
+	<input type="image" src="/submit.png">
+	</body></html>`
+	doc, _ := html.Parse(strings.NewReader(h))
+	base, _ := url.Parse("http://localhost")
+
+	want := map[string]string{
+		"/site.css": model.LinkKindStylesheet, "/font.woff2": model.LinkKindPreload, "/favicon.ico": model.LinkKindOther,
+		"/app.js": model.LinkKindScript, "/area": model.LinkKindAnchor, "/hero.webp": model.LinkKindImage,
+		"/clip.mp4": model.LinkKindMedia, "/clip.webm": model.LinkKindMedia, "https://embed.example/player": model.LinkKindFrame,
+		"/submit.png": model.LinkKindImage,
+	}
+	kinds := map[string][]string{}
+	for _, l := range util.ExtractLinks(doc, base) {
+		kinds[l.Href] = append(kinds[l.Href], l.Kind)
+	}
+	for href, kind := range want {
+		if len(kinds[href]) != 1 || kinds[href][0] != kind {
+			t.Errorf("%s: expected kind %s, got %v", href, kind, kinds[href])
+		}
+	}
+	// The same URL referenced as an anchor and an image is reported once per kind.
+	if got := kinds["/page"]; len(got) != 2 || got[0] != model.LinkKindAnchor || got[1] != model.LinkKindImage {
+		t.Errorf("/page: expected anchor and image entries, got %v", got)
+	}
+}
+
+func TestExtractLinks_siteRelation(t *testing.T) {
+	h := `<html><head><base href="https://www.example.com/docs/"></head><body>
+	<a href="guide">Guide</a>
+	<a href="https://www.example.com:8443/admin">Admin</a>
+	<a href="https://example.com/">Apex</a>
+	<a href="https://blog.example.com/post">Blog</a>
+	<a href="https://alice.github.io/">Alice</a>
+	<a href="https://other.example/">Other</a>
+	</body></html>`
+	doc, _ := html.Parse(strings.NewReader(h))
+	page, _ := url.Parse("https://www.example.com/")
+
+	want := map[string]string{
+		"https://www.example.com/docs/guide": model.SiteSameHost, "https://www.example.com:8443/admin": model.SiteSameHost,
+		"https://example.com/": model.SiteSameSite, "https://blog.example.com/post": model.SiteSameSite,
+		"https://alice.github.io/": model.SiteCrossSite, "https://other.example/": model.SiteCrossSite,
+	}
+	links := util.ExtractLinks(doc, page)
+	for _, l := range links {
+		if l.Site != want[l.URL] || l.Internal != (l.Site == model.SiteSameHost) {
+			t.Errorf("%s: expected site %q, got %q (internal %v)", l.URL, want[l.URL], l.Site, l.Internal)
+		}
+	}
+	if len(links) != len(want) {
+		t.Fatalf("expected %d links resolved against <base href>, got %+v", len(want), links)
+	}
+
+	result := &model.AnalyzeResult{}
+	if err := (&LinksStrategy{}).AnalyzeContext(context.Background(), doc, page, result); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Links.Internal != 2 || result.Links.External != 4 {
+		t.Fatalf("expected the default scope to count only same-host links as internal, got %+v", result.Links)
+	}
+	result = &model.AnalyzeResult{}
+	if err := (&LinksStrategy{Scope: LinkScopeSite}).AnalyzeContext(context.Background(), doc, page, result); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Links.Internal != 4 || result.Links.External != 2 {
+		t.Fatalf("expected site scope to count same-site links as internal, got %+v", result.Links)
+	}
+}
+
+func TestExtractLinks_normalization(t *testing.T) {
+	h := `<html><body>
+	<a href="HTTP://Example.com:80/a">One</a>
+	<a href="http://example.com/a?utm_source=x&amp;fbclid=y">Two</a>
+	<a href="http://example.com/a#top">Three</a>
+	<a href="http://example.com/a#x">Four</a>
+	<a href="https://bücher.example:443/?b=2&amp;a=1&amp;UTM_Medium=z">Books</a>
+	<a href="https://xn--bcher-kva.example/?a=1&amp;b=2">Books again</a>
+	</body></html>`
+	doc, _ := html.Parse(strings.NewReader(h))
+	page, _ := url.Parse("http://example.com/")
+
+	links := util.ExtractLinksWith(doc, page, util.URLNormalizer{TrackingParams: util.DefaultTrackingParams, SortQuery: true})
+	if len(links) != 2 {
+		t.Fatalf("expected 2 entries, got %+v", links)
+	}
+	// Fragments are dropped from the URL and kept on the entry for validation.
+	if a := links[0]; a.URL != "http://example.com/a" || a.Occurrences != 4 || a.Href != "HTTP://Example.com:80/a" ||
+		!slices.Equal(a.Variants, []string{"http://example.com/a?utm_source=x&fbclid=y", "http://example.com/a#top", "http://example.com/a#x"}) ||
+		!slices.Equal(a.Fragments, []string{"top", "x"}) {
+		t.Fatalf("unexpected normalized entry: %+v", a)
+	}
+	if books := links[1]; books.URL != "https://xn--bcher-kva.example/?a=1&b=2" || books.Occurrences != 2 {
+		t.Fatalf("unexpected IDN entry: %+v", books)
+	}
+}
+
+func TestLinksStrategy_attributeAudit(t *testing.T) {
+	h := `<html><body>
+	<a href="https://partner.example/" rel="Sponsored nofollow" target="_blank">Partner</a>
+	<a href="https://partner.example/" target="_blank">Partner again</a>
+	<a href="https://forum.example/" rel="ugc noreferrer" target="_blank">Forum</a>
+	<a href="/de/" hreflang="de">Deutsch</a>
+	<a href="/report.pdf" download="report-2024.pdf">Report</a>
+	<link rel="alternate" hreflang="fr" href="/fr/">
+	</body></html>`
+	doc, _ := html.Parse(strings.NewReader(h))
+	page, _ := url.Parse("https://site.example/")
+	result := &model.AnalyzeResult{}
+	if err := (&LinksStrategy{}).AnalyzeContext(context.Background(), doc, page, result); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	audit := result.LinkAudit
+	if audit == nil || audit.Sponsored != 1 || audit.Nofollow != 1 || audit.UGC != 1 || audit.Noreferrer != 1 ||
+		audit.TargetBlank != 3 || audit.UnsafeTargetBlank != 2 || audit.Hreflang != 1 || audit.Download != 1 {
+		t.Fatalf("unexpected audit counts: %+v", audit)
+	}
+	want := []model.LinkFinding{
+		{Issue: model.FindingUnsafeTargetBlank, URL: "https://partner.example/", Href: "https://partner.example/", Occurrences: 2},
+		{Issue: model.FindingHreflang, URL: "https://site.example/de/", Href: "/de/", Value: "de", Occurrences: 1},
+		{Issue: model.FindingDownload, URL: "https://site.example/report.pdf", Href: "/report.pdf", Value: "report-2024.pdf", Occurrences: 1},
+	}
+	if !slices.Equal(audit.Findings, want) {
+		t.Fatalf("expected findings %+v, got %+v", want, audit.Findings)
+	}
+	partner := result.LinkDetails[0]
+	if !slices.Equal(partner.Rel, []string{"sponsored", "nofollow"}) || partner.Target != "_blank" || partner.Occurrences != 2 {
+		t.Fatalf("unexpected partner detail: %+v", partner)
+	}
+}
+
+func TestSEOStrategy(t *testing.T) {
+	h := `<html><head>
+	<title>Widgets</title>
+	<title>Widgets again</title>
+	<meta name="description" content="` + strings.Repeat("Widgets ", 25) + `">
+	<meta name="Keywords" content="widgets, gadgets,">
+	<link rel="canonical" href="/widgets?utm_source=feed">
+	</head><body></body></html>`
+	doc, _ := html.Parse(strings.NewReader(h))
+	page, _ := url.Parse("https://shop.example/widgets?page=2")
+	header := http.Header{"X-Robots-Tag": {"googlebot: noindex, nofollow, max-snippet: 50"}}
+	result := &model.AnalyzeResult{}
+	if err := (&SEOStrategy{Header: header}).Analyze(doc, page, result); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	seo := result.SEO
+	if seo == nil || seo.Title.Text != "Widgets" || seo.Title.Chars != 7 || seo.Description.Chars != 199 || seo.Description.Pixels != 1358 {
+		t.Fatalf("unexpected texts: %+v", seo)
+	}
+	if !slices.Equal(seo.Keywords, []string{"widgets", "gadgets"}) || seo.Canonical != "https://shop.example/widgets" {
+		t.Fatalf("unexpected keywords or canonical: %+v", seo)
+	}
+	if !slices.Equal(seo.XRobotsTag, []string{"googlebot: noindex", "googlebot: nofollow", "googlebot: max-snippet: 50"}) {
+		t.Fatalf("unexpected X-Robots-Tag directives: %q", seo.XRobotsTag)
+	}
+	want := []model.SEOFinding{
+		{Field: model.SEOFieldTitle, Issue: model.SEODuplicate, Value: "Widgets again"},
+		{Field: model.SEOFieldTitle, Issue: model.SEOTooShort, Chars: 7, Pixels: 72},
+		{Field: model.SEOFieldDescription, Issue: model.SEOTooLong, Chars: 199, Pixels: 1358},
+		{Field: model.SEOFieldViewport, Issue: model.SEOMissing},
+		{Field: model.SEOFieldCanonical, Issue: model.SEOCanonicalized, Value: "https://shop.example/widgets", BlocksIndexing: true},
+	}
+	if !slices.Equal(seo.Findings, want) || seo.Indexable {
+		t.Fatalf("expected findings %+v, got %+v (indexable %v)", want, seo.Findings, seo.Indexable)
+	}
+
+	// Directives without an agent prefix apply.
+	header = http.Header{"X-Robots-Tag": {"NoIndex"}}
+	h = `<meta name="robots" content="index"><meta name="robots" content="none"><link rel="canonical" href="https://shop.example/widgets?page=2">`
+	doc, _ = html.Parse(strings.NewReader(h))
+	if err := (&SEOStrategy{Header: header}).Analyze(doc, page, result); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var blocking []string
+	for _, f := range result.SEO.Findings {
+		if f.BlocksIndexing {
+			blocking = append(blocking, f.Field+":"+f.Issue)
+		}
+	}
+	if result.SEO.Indexable || !slices.Equal(blocking, []string{"robots:noindex", "x_robots_tag:noindex"}) {
+		t.Fatalf("unexpected verdict %v from %q", result.SEO.Indexable, blocking)
+	}
+}
+
+func TestSEOStrategy_xRobotsTagLines(t *testing.T) {
+	doc, _ := html.Parse(strings.NewReader(`<link rel="canonical" href="https://shop.example/widgets">`))
+	page, _ := url.Parse("https://shop.example/widgets")
+	// An agent prefix on one header line does not carry over to the next.
+	header := http.Header{"X-Robots-Tag": {"googlebot: nofollow", "noindex"}}
+	result := &model.AnalyzeResult{}
+	if err := (&SEOStrategy{Header: header}).Analyze(doc, page, result); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(result.SEO.XRobotsTag, []string{"googlebot: nofollow", "noindex"}) || result.SEO.Indexable {
+		t.Fatalf("expected a generic noindex, got %q (indexable %v)", result.SEO.XRobotsTag, result.SEO.Indexable)
+	}
+}
+
+func TestSummarizeExternalDomains(t *testing.T) {
+	links := []model.LinkDetail{
+		{URL: "https://site.example/", Internal: true, Occurrences: 5},
+		{URL: "https://cdn.partner.example/app.js", Kind: model.LinkKindScript, Occurrences: 1},
+		{URL: "https://www.partner.example/", Occurrences: 2, Checked: true, LinkCheck: model.LinkCheck{Accessible: true}},
+		{URL: "http://www.partner.example/", Kind: model.LinkKindImage, Occurrences: 1, Checked: true,
+			LinkCheck: model.LinkCheck{Accessible: true, RedirectURL: "https://www.partner.example/"}},
+		{URL: "http://legacy.example/", Occurrences: 1, Checked: true, LinkCheck: model.LinkCheck{Accessible: false}},
+		{URL: "http://legacy.example/about", Occurrences: 2},
+		{URL: "http://legacy.example/admin", Occurrences: 1, Checked: true,
+			LinkCheck: model.LinkCheck{Category: model.LinkDisallowedByRobots, RobotsDisallowed: true}},
+		{URL: "http://192.0.2.1:8080/", Occurrences: 3},
+	}
+	want := []model.DomainStats{
+		{Domain: "legacy.example", Links: 4, UniqueURLs: 3, Inaccessible: 1},
+		{Domain: "partner.example", Links: 4, UniqueURLs: 3, HTTPS: true},
+		{Domain: "192.0.2.1", Links: 3, UniqueURLs: 1},
+	}
+	if got := util.SummarizeExternalDomains(links); !slices.Equal(got, want) {
+		t.Fatalf("expected %+v, got %+v", want, got)
+	}
+}
+
+func TestLinksStrategy_sample(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+	var b strings.Builder
+	for i := range 10 {
+		fmt.Fprintf(&b, `<a href="/page-%d">Page</a>`, i)
+	}
+	doc, _ := html.Parse(strings.NewReader(b.String()))
+	base, _ := url.Parse(srv.URL)
+	result := &model.AnalyzeResult{}
+	s := &LinksStrategy{LinkChecker: &factory.DefaultLinkChecker{Client: srv.Client()}, Sample: 4}
+	if err := s.AnalyzeContext(context.Background(), doc, base, result); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	sample := result.LinkSample
+	if sample == nil || sample.Population != 10 || sample.Size != 4 || sample.Checked != 4 || sample.Inaccessible != 4 ||
+		sample.EstimatedInaccessible != 10 || sample.Low < 4 || sample.Low > 10 || sample.High != 10 {
+		t.Fatalf("unexpected sample: %+v", sample)
+	}
+	if result.Links.Unchecked != 6 || result.Links.Inaccessible != 4 {
+		t.Fatalf("expected only the sampled links to be checked, got %+v", result.Links)
+	}
+}
+
+func TestEstimateInaccessible(t *testing.T) {
+	cases := []struct{ k, n, size, estimate, low, high int }{
+		{3, 10, 10, 3, 3, 3}, // everything checked: exact
+		{0, 0, 8, 0, 0, 8},   // nothing checked: no information
+		{5, 50, 1000, 100, 44, 211},
+	}
+	for _, tc := range cases {
+		estimate, low, high := estimateInaccessible(tc.k, tc.n, tc.size)
+		if estimate != tc.estimate || low != tc.low || high != tc.high {
+			t.Errorf("estimateInaccessible(%d, %d, %d) = %d [%d, %d], want %d [%d, %d]",
+				tc.k, tc.n, tc.size, estimate, low, high, tc.estimate, tc.low, tc.high)
+		}
+	}
+}
+
+func TestSummarizeLinks_blockedIsNotBroken(t *testing.T) {
+	links := []model.LinkDetail{
+		{Occurrences: 2, Internal: true, Checked: true, LinkCheck: model.LinkCheck{Accessible: true, Category: model.LinkOK}},
+		{Occurrences: 1, Checked: true, LinkCheck: model.LinkCheck{Category: model.LinkAuthRequired, Blocked: true}},
+		{Occurrences: 1, Checked: true, LinkCheck: model.LinkCheck{Category: model.LinkRateLimited}},
+		{Occurrences: 3, Checked: true, LinkCheck: model.LinkCheck{Category: model.LinkDNSError}},
+		{Occurrences: 2, Checked: true, LinkCheck: model.LinkCheck{Category: model.LinkDisallowedByRobots, RobotsDisallowed: true}},
+	}
+	want := model.LinkStats{Internal: 2, External: 2, Inaccessible: 5, Broken: 3, Blocked: 1, DisallowedByRobots: 2,
+		Breakdown: model.LinkBreakdown{OK: 2, AuthRequired: 1, RateLimited: 1, DNSError: 3, DisallowedByRobots: 2}}
+	if got := util.SummarizeLinks(links); got != want {
+		t.Fatalf("expected %+v, got %+v", want, got)
+	}
+}
+
+func TestAnalyzer_fragmentValidation(t *testing.T) {
+	a := New(
+		WithFetcher(fixtureFetcher{
+			"http://fixture.test/": `<html><body>
+				<h2 id="present">Here</h2>
+				<a href="#present">ok</a><a href="#missing">bad</a><a href="#top">top</a>
+				<a href="/docs#install">install</a><a href="/docs#legacy">legacy</a><a href="/docs#nope">nope</a>
+			</body></html>`,
+			"http://fixture.test/docs": `<html><body><h2 id="install">Install</h2><a name="legacy"></a></body></html>`,
+		}),
+		WithLinkChecker(&mockChecker{}),
+		WithMetrics(&recordingSink{}),
+	)
+
+	res, err := a.AnalyzePage(context.Background(), "http://fixture.test/", Options{Strategies: []string{StrategyLinks}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	broken := map[string][]string{}
+	for _, l := range res.LinkDetails {
+		if !l.Checked {
+			t.Fatalf("expected every link to be checked: %+v", l)
+		}
+		if l.Category == model.LinkBrokenFragment {
+			broken[l.URL] = l.BrokenFragments
+		}
+	}
+	if len(res.LinkDetails) != 2 || !slices.Equal(broken["http://fixture.test/"], []string{"missing"}) ||
+		!slices.Equal(broken["http://fixture.test/docs"], []string{"nope"}) {
+		t.Fatalf("expected #missing and /docs#nope to be broken fragments, got %v", broken)
+	}
+	if res.Links.Breakdown.BrokenFragment != 6 || res.Links.Broken != 6 {
+		t.Fatalf("unexpected stats: %+v", res.Links)
+	}
+}
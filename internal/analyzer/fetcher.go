@@ -0,0 +1,217 @@
+package analyzer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	appErr "web-analyzer-go/internal/errors"
+	"web-analyzer-go/internal/factory"
+	"web-analyzer-go/internal/model"
+	"web-analyzer-go/internal/util"
+)
+
+// FetchOptions are the per-request settings a Fetcher must honor.
+type FetchOptions struct {
+	UserAgent    string
+	MaxBodyBytes int64
+}
+
+// FetchResponse is the raw document returned by a Fetcher. Info describes the
+// exchange; fetchers that are not HTTP based may leave most of it empty.
+type FetchResponse struct {
+	Body     []byte
+	Header   http.Header
+	FinalURL string
+	Info     model.FetchInfo
+}
+
+// Fetcher retrieves the document to analyze. Each fetcher decides which URL
+// schemes it serves and rejects the others. Errors should be *errors.AppError
+// values so the API can map them to status codes.
+type Fetcher interface {
+	Fetch(ctx context.Context, targetURL string, opts FetchOptions) (*FetchResponse, error)
+}
+
+// HTTPFetcher fetches pages over HTTP(S) with the given client. Other schemes
+// are rejected with a validation error.
+type HTTPFetcher struct {
+	Client *http.Client
+	Logger *slog.Logger
+}
+
+// reportedHeaders lists the response headers copied into model.FetchInfo.
+var reportedHeaders = []string{
+	"Content-Type",
+	"Content-Language",
+	"Content-Encoding",
+	"Cache-Control",
+	"ETag",
+	"Last-Modified",
+	"Server",
+	"X-Robots-Tag",
+}
+
+// Fetch implements Fetcher.
+func (f *HTTPFetcher) Fetch(ctx context.Context, targetURL string, opts FetchOptions) (*FetchResponse, error) {
+	req, err := f.buildGetRequest(ctx, targetURL, opts.UserAgent)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkHTTPURL(req.URL); err != nil {
+		f.logInfo("http.unsupported_url", slog.String("url", targetURL))
+		return nil, err
+	}
+	return f.do(req, opts.MaxBodyBytes)
+}
+
+// buildGetRequest constructs a GET request with the appropriate headers.
+func (f *HTTPFetcher) buildGetRequest(ctx context.Context, targetURL, userAgent string) (*http.Request, error) {
+	f.logInfo("http.fetch", slog.String("url", targetURL))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, targetURL, nil)
+	if err != nil {
+		f.logError("http.request_build_failed", slog.String("url", targetURL), slog.String("error", err.Error()))
+		return nil, appErr.NewInternalError("failed to create HTTP request", err)
+	}
+	req.Header.Set("User-Agent", userAgent)
+	return req, nil
+}
+
+// do executes the request and reads at most maxBodyBytes of a successful response.
+func (f *HTTPFetcher) do(req *http.Request, maxBodyBytes int64) (*FetchResponse, error) {
+	start := time.Now()
+	resp, err := f.client().Do(req)
+	if err != nil {
+		var blocked *factory.BlockedError
+		if errors.As(err, &blocked) {
+			f.logError("http.blocked", slog.String("url", req.URL.String()), slog.String("reason", blocked.Reason))
+			return nil, appErr.NewForbiddenError(fmt.Sprintf("URL %s resolves to a blocked address", req.URL.String()), blocked)
+		}
+		if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+			f.logError("http.timeout", slog.String("url", req.URL.String()))
+			return nil, appErr.NewTimeoutError(fmt.Sprintf("request to %s", req.URL.String()), err)
+		}
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			f.logError("http.timeout", slog.String("url", req.URL.String()))
+			return nil, appErr.NewTimeoutError(fmt.Sprintf("request to %s", req.URL.String()), err)
+		}
+		f.logError("http.error", slog.String("url", req.URL.String()), slog.String("error", ErrUnreachable.Error()))
+		return nil, appErr.WrapError(err, appErr.ErrorTypeUnavailable, fmt.Sprintf("URL %s is unreachable", req.URL.String()))
+	}
+	defer resp.Body.Close()
+
+	f.logInfo("http.response", slog.Int("status", resp.StatusCode), slog.String("status_text", resp.Status))
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		f.logError("http.non_2xx", slog.Int("status", resp.StatusCode), slog.String("status_text", resp.Status))
+		return nil, appErr.NewUpstreamError(req.URL.Host, resp.StatusCode, fmt.Errorf("received status: %s", resp.Status))
+	}
+
+	// Read one byte past the cap so truncation can be detected.
+	body, readErr := io.ReadAll(io.LimitReader(resp.Body, maxBodyBytes+1))
+	if readErr != nil {
+		f.logError("http.read_error", slog.String("url", req.URL.String()), slog.String("error", readErr.Error()))
+		return nil, appErr.WrapError(readErr, appErr.ErrorTypeUnavailable, fmt.Sprintf("failed to read response from %s", req.URL.String()))
+	}
+	truncated := int64(len(body)) > maxBodyBytes
+	if truncated {
+		body = body[:maxBodyBytes]
+		f.logInfo("http.body_truncated", slog.Int64("limit_bytes", maxBodyBytes))
+	}
+
+	return &FetchResponse{
+		Body:     body,
+		Header:   resp.Header,
+		FinalURL: resp.Request.URL.String(),
+		Info:     buildFetchInfo(resp, int64(len(body)), truncated, time.Since(start)),
+	}, nil
+}
+
+func (f *HTTPFetcher) client() *http.Client {
+	if f.Client != nil {
+		return f.Client
+	}
+	return (&factory.DefaultHTTPClientFactory{}).NewClient()
+}
+
+func (f *HTTPFetcher) logInfo(message string, args ...any) {
+	f.log().Info(message, args...)
+}
+
+func (f *HTTPFetcher) logError(message string, args ...any) {
+	f.log().Error(message, args...)
+}
+
+func (f *HTTPFetcher) log() *slog.Logger {
+	if f.Logger != nil {
+		return f.Logger
+	}
+	return util.EnsureLogger().With(slog.String("component", "analyzer"))
+}
+
+// buildFetchInfo summarises the HTTP exchange, walking back through the
+// redirect responses the client recorded on each follow-up request.
+func buildFetchInfo(resp *http.Response, bytesRead int64, truncated bool, d time.Duration) model.FetchInfo {
+	info := model.FetchInfo{
+		FinalURL:      resp.Request.URL.String(),
+		StatusCode:    resp.StatusCode,
+		Headers:       make(map[string]string),
+		ContentLength: resp.ContentLength,
+		Protocol:      resp.Proto,
+		DurationMs:    d.Milliseconds(),
+		BytesRead:     bytesRead,
+		Truncated:     truncated,
+	}
+	for _, h := range reportedHeaders {
+		// Repeated headers, such as several X-Robots-Tag lines, are combined.
+		if v := strings.Join(resp.Header.Values(h), ", "); v != "" {
+			info.Headers[strings.ToLower(h)] = v
+		}
+	}
+
+	var chain []model.Redirect
+	for r := resp.Request; r != nil && r.Response != nil; r = r.Response.Request {
+		prev := r.Response
+		chain = append(chain, model.Redirect{
+			URL:        prev.Request.URL.String(),
+			StatusCode: prev.StatusCode,
+			Location:   r.URL.String(),
+		})
+	}
+	// The walk above runs from the last hop to the first.
+	for i, j := 0, len(chain)-1; i < j; i, j = i+1, j-1 {
+		chain[i], chain[j] = chain[j], chain[i]
+	}
+	info.Redirects = chain
+	return info
+}
+
+// gatedFetcher fetches link targets through gate, so they are paced and
+// subject to robots.txt like link checks.
+type gatedFetcher struct {
+	next Fetcher
+	gate *factory.FetchGate
+}
+
+func (f gatedFetcher) Fetch(ctx context.Context, targetURL string, opts FetchOptions) (*FetchResponse, error) {
+	var resp *FetchResponse
+	err := f.gate.Do(ctx, targetURL, func() error {
+		var err error
+		resp, err = f.next.Fetch(ctx, targetURL, opts)
+		return err
+	})
+	if errors.Is(err, factory.ErrRobotsDisallowed) {
+		return nil, appErr.NewForbiddenError("robots.txt disallows fetching this page", fmt.Errorf("user-agent %s: %s", factory.RobotsAgent, targetURL))
+	}
+	if _, isApp := appErr.GetAppError(err); err != nil && !isApp {
+		// The gate gave up waiting for a slot.
+		return nil, appErr.NewTimeoutError(fmt.Sprintf("request to %s", targetURL), err)
+	}
+	return resp, err
+}
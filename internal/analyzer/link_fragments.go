@@ -0,0 +1,101 @@
+package analyzer
+
+import (
+	"bytes"
+	"context"
+	"net/url"
+	"web-analyzer-go/internal/model"
+	"web-analyzer-go/internal/util"
+
+	"golang.org/x/net/html"
+	"golang.org/x/sync/errgroup"
+)
+
+// fragmentPageLimit caps how many linked pages are fetched concurrently to
+// validate fragments, and fragmentPageBytes how much of each is read when the
+// request does not set a body limit.
+const (
+	fragmentPageLimit = 4
+	fragmentPageBytes = 1 << 20
+)
+
+// checkPageFragments fetches each internal page that accessible links point
+// into with fragments, once per page, and marks the links whose fragments it
+// lacks. Pages that cannot be fetched or parsed leave their links as checked.
+func (s *LinksStrategy) checkPageFragments(ctx context.Context, links []model.LinkDetail) error {
+	pages := make(map[string][]int)
+	var order []string
+	for i, l := range links {
+		if !l.Internal || l.SamePage || !l.Checked || !l.Accessible || !util.NeedsFragmentTargets(l) {
+			continue
+		}
+		page := fragmentPageURL(l)
+		if _, ok := pages[page]; !ok {
+			order = append(order, page)
+		}
+		pages[page] = append(pages[page], i)
+	}
+	if len(order) == 0 {
+		return nil
+	}
+
+	maxBytes := s.MaxBodyBytes
+	if maxBytes <= 0 || maxBytes > fragmentPageBytes {
+		maxBytes = fragmentPageBytes
+	}
+	group := &errgroup.Group{}
+	group.SetLimit(fragmentPageLimit)
+	for _, page := range order {
+		if ctx.Err() != nil {
+			break
+		}
+		group.Go(func() error {
+			targets, ok := s.fetchFragmentTargets(ctx, page, maxBytes)
+			if !ok || ctx.Err() != nil {
+				return nil
+			}
+			// Each page owns distinct link indexes, so no locking is needed.
+			for _, i := range pages[page] {
+				util.MarkFragment(&links[i], targets)
+			}
+			return nil
+		})
+	}
+	group.Wait()
+	return ctx.Err()
+}
+
+// fragmentPageURL is the page a link's fragment is looked up in: where the
+// link ended up after redirects, without the fragment.
+func fragmentPageURL(l model.LinkDetail) string {
+	target := l.URL
+	if l.RedirectURL != "" {
+		target = l.RedirectURL
+	}
+	u, err := url.Parse(target)
+	if err != nil {
+		return target
+	}
+	u.Fragment, u.RawFragment = "", ""
+	return u.String()
+}
+
+// fetchFragmentTargets fetches and parses page and returns its fragment
+// targets. ok is false if the page is not HTML, could not be retrieved or was
+// truncated, since a missing target would then prove nothing.
+func (s *LinksStrategy) fetchFragmentTargets(ctx context.Context, page string, maxBytes int64) (targets map[string]bool, ok bool) {
+	resp, err := s.Fetcher.Fetch(ctx, page, FetchOptions{UserAgent: s.UserAgent, MaxBodyBytes: maxBytes})
+	if err != nil || resp.Info.Truncated {
+		return nil, false
+	}
+	contentType := resp.Header.Get("Content-Type")
+	if _, _, isHTML := util.DetectMediaType(contentType, resp.Body); !isHTML {
+		return nil, false
+	}
+	decoded, _ := util.DecodeHTML(resp.Body, contentType)
+	doc, err := html.Parse(bytes.NewReader(decoded))
+	if err != nil {
+		return nil, false
+	}
+	return util.FragmentTargets(doc), true
+}
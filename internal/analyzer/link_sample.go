@@ -0,0 +1,96 @@
+package analyzer
+
+import (
+	"context"
+	"math"
+	"math/rand/v2"
+	"web-analyzer-go/internal/model"
+	"web-analyzer-go/internal/util"
+)
+
+// sampleConfidence is the confidence level of the interval reported for a
+// sampled link check, and sampleZ the matching normal quantile.
+const (
+	sampleConfidence = 0.95
+	sampleZ          = 1.959964
+)
+
+// sampleSize is the number of links the sample mode checks, or 0 when every
+// link is checked.
+func sampleSize(opts Options) int {
+	if opts.LinkCheck != LinkCheckSample {
+		return 0
+	}
+	return opts.LinkSample
+}
+
+// checkLinkSample checks up to n randomly chosen unique URLs among the links
+// not yet checked and estimates how many of them all are inaccessible. The
+// estimate covers the URLs finished before ctx was done, whose error is
+// returned.
+func checkLinkSample(ctx context.Context, links []model.LinkDetail, check func(context.Context, string) model.LinkCheck, n int) (*model.LinkSample, error) {
+	seen := make(map[string]bool)
+	var urls []string
+	for _, l := range links {
+		if !l.Checked && !seen[l.URL] {
+			seen[l.URL] = true
+			urls = append(urls, l.URL)
+		}
+	}
+	rand.Shuffle(len(urls), func(i, j int) { urls[i], urls[j] = urls[j], urls[i] })
+	chosen := make(map[string]bool)
+	for _, u := range urls[:min(n, len(urls))] {
+		chosen[u] = true
+	}
+
+	var indexes []int
+	var subset []model.LinkDetail
+	for i, l := range links {
+		if chosen[l.URL] && !l.Checked {
+			indexes = append(indexes, i)
+			subset = append(subset, l)
+		}
+	}
+	err := util.CheckLinks(ctx, subset, check)
+	for j, i := range indexes {
+		links[i] = subset[j]
+	}
+
+	sample := &model.LinkSample{Population: len(urls), Size: len(chosen), Confidence: sampleConfidence}
+	counted := make(map[string]bool)
+	for _, l := range subset {
+		if !l.Checked || counted[l.URL] {
+			continue
+		}
+		counted[l.URL] = true
+		sample.Checked++
+		if !l.Accessible && l.Category != model.LinkDisallowedByRobots {
+			sample.Inaccessible++
+		}
+	}
+	sample.EstimatedInaccessible, sample.Low, sample.High = estimateInaccessible(sample.Inaccessible, sample.Checked, sample.Population)
+	return sample, err
+}
+
+// estimateInaccessible extrapolates k inaccessible out of n checked URLs to a
+// population of size, with a Wilson score interval narrowed by the finite
+// population correction so that checking every URL yields the exact count.
+// The bounds never contradict what the sample already established.
+func estimateInaccessible(k, n, size int) (estimate, low, high int) {
+	if n == 0 {
+		return 0, 0, size
+	}
+	p := float64(k) / float64(n)
+	z := sampleZ
+	if size > 1 {
+		z *= math.Sqrt(float64(size-n) / float64(size-1))
+	}
+	nf := float64(n)
+	center := (p + z*z/(2*nf)) / (1 + z*z/nf)
+	margin := z / (1 + z*z/nf) * math.Sqrt(p*(1-p)/nf+z*z/(4*nf*nf))
+
+	estimate = int(math.Round(p * float64(size)))
+	low = max(int(math.Floor((center-margin)*float64(size))), k)
+	high = min(int(math.Ceil((center+margin)*float64(size))), size-(n-k))
+	return estimate, min(low, estimate), max(high, estimate)
+}
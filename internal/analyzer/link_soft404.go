@@ -0,0 +1,53 @@
+package analyzer
+
+import (
+	"context"
+	"web-analyzer-go/internal/model"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// soft404Limit caps how many pages are examined for soft 404s concurrently.
+const soft404Limit = 4
+
+// checkSoft404 runs the soft-404 detector over accessible anchors that
+// answered with a success status, or with no status from checkers that only
+// report accessibility. Each URL is examined once for all its entries.
+func (s *LinksStrategy) checkSoft404(ctx context.Context, links []model.LinkDetail) error {
+	byURL := make(map[string][]int)
+	var urls []string
+	for i, l := range links {
+		if l.Kind != model.LinkKindAnchor || l.SamePage || !l.Checked || !l.Accessible {
+			continue
+		}
+		if l.StatusCode != 0 && (l.StatusCode < 200 || l.StatusCode >= 300) {
+			continue
+		}
+		if _, ok := byURL[l.URL]; !ok {
+			urls = append(urls, l.URL)
+		}
+		byURL[l.URL] = append(byURL[l.URL], i)
+	}
+
+	group := &errgroup.Group{}
+	group.SetLimit(soft404Limit)
+	for _, u := range urls {
+		if ctx.Err() != nil {
+			break
+		}
+		group.Go(func() error {
+			res := s.Soft404.Check(ctx, u)
+			if ctx.Err() != nil {
+				return nil
+			}
+			// Each goroutine owns its URL's entries, so no locking is needed.
+			for _, i := range byURL[u] {
+				links[i].Soft404 = res.Likely
+				links[i].Soft404Confidence = res.Confidence
+			}
+			return nil
+		})
+	}
+	group.Wait()
+	return ctx.Err()
+}
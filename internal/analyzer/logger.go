@@ -1,14 +1,9 @@
 package analyzer
 
-import (
-	"log/slog"
-	"web-analyzer-go/internal/util"
-)
-
-func logInfo(message string, args ...any) {
-	util.EnsureLogger().Info(message, append(args, slog.String("component", "analyzer"))...)
+func (a *Analyzer) logInfo(message string, args ...any) {
+	a.logger.Info(message, args...)
 }
 
-func logError(message string, args ...any) {
-	util.EnsureLogger().Error(message, append(args, slog.String("component", "analyzer"))...)
+func (a *Analyzer) logError(message string, args ...any) {
+	a.logger.Error(message, args...)
 }
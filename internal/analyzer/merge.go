@@ -12,9 +12,27 @@ func mergeAnalyzeResult(main, partial *model.AnalyzeResult) {
 	if len(partial.Headings) > 0 {
 		main.Headings = partial.Headings
 	}
-	if partial.Links.Internal != 0 || partial.Links.External != 0 || partial.Links.Inaccessible != 0 {
+	if partial.Links != (model.LinkStats{}) {
 		main.Links = partial.Links
 	}
+	if len(partial.LinkKinds) > 0 {
+		main.LinkKinds = partial.LinkKinds
+	}
+	if len(partial.LinkDetails) > 0 {
+		main.LinkDetails = partial.LinkDetails
+	}
+	if len(partial.ExternalDomains) > 0 {
+		main.ExternalDomains = partial.ExternalDomains
+	}
+	if partial.LinkSample != nil {
+		main.LinkSample = partial.LinkSample
+	}
+	if partial.LinkAudit != nil {
+		main.LinkAudit = partial.LinkAudit
+	}
+	if partial.SEO != nil {
+		main.SEO = partial.SEO
+	}
 	if partial.LoginForm {
 		main.LoginForm = true
 	}
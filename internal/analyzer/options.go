@@ -0,0 +1,191 @@
+package analyzer
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	appErr "web-analyzer-go/internal/errors"
+	"web-analyzer-go/internal/factory"
+
+	"golang.org/x/net/http/httpguts"
+)
+
+// Strategy names accepted in Options.Strategies.
+const (
+	StrategyHTMLVersion = "html_version"
+	StrategyTitle       = "title"
+	StrategyHeadings    = "headings"
+	StrategyLinks       = "links"
+	StrategyLoginForm   = "login_form"
+	StrategySEO         = "seo"
+)
+
+// Link-check modes accepted in Options.LinkCheck. Full probes each link with
+// HEAD and falls back to a ranged GET, head sends HEAD only, sample fully
+// checks a random subset and estimates the rest, and none makes no requests.
+const (
+	LinkCheckFull   = "full"
+	LinkCheckHead   = "head"
+	LinkCheckSample = "sample"
+	LinkCheckNone   = "none"
+)
+
+// Link scopes accepted in Options.LinkScope. They decide which links count as
+// internal: those on the page's own host (the default), or anywhere on its
+// registrable domain (so www.example.com, example.com and blog.example.com
+// are one site).
+const (
+	LinkScopeHost = "host"
+	LinkScopeSite = "site"
+)
+
+// Robots policies accepted in Options.Robots: ignore robots.txt, report the
+// page and links it disallows, or also refuse to fetch them.
+const (
+	RobotsIgnore     = "ignore"
+	RobotsReportOnly = "report_only"
+	RobotsObey       = "obey"
+)
+
+// Options tunes a single analysis. Zero values mean "use the server default".
+type Options struct {
+	Timeout      time.Duration
+	MaxBodyBytes int64
+	UserAgent    string
+	Strategies   []string
+	LinkCheck    string
+	// LinkSample is how many unique links the sample mode checks.
+	LinkSample int
+	LinkScope  string
+	// SortQuery orders query parameters by name when normalizing link URLs,
+	// so links differing only in parameter order are checked once.
+	SortQuery bool
+	// Robots is the robots.txt policy; empty uses Limits.DefaultRobots.
+	Robots string
+	// Strict fails the whole analysis on the first strategy error instead of
+	// returning partial results with warnings.
+	Strict bool
+	// Soft404 fetches the body of accessible anchor links to flag pages that
+	// answer 200 but look like "not found" pages. It needs link checking.
+	Soft404 bool
+}
+
+// Limits are the server-side defaults and ceilings applied to Options.
+type Limits struct {
+	DefaultTimeout      time.Duration
+	MaxTimeout          time.Duration
+	DefaultMaxBodyBytes int64
+	MaxBodyBytes        int64
+	// DefaultLinkCheck is the link-check mode for requests that set none;
+	// empty means LinkCheckFull. DefaultLinkSample is the sample size and
+	// MaxLinkSample the largest one a request may ask for.
+	DefaultLinkCheck  string
+	DefaultLinkSample int
+	MaxLinkSample     int
+	// DefaultRobots is the robots.txt policy for requests that set none;
+	// empty means RobotsIgnore.
+	DefaultRobots string
+}
+
+// DefaultLimits matches the behaviour before per-request options existed.
+func DefaultLimits() Limits {
+	return Limits{
+		DefaultTimeout:      15 * time.Second,
+		MaxTimeout:          25 * time.Second,
+		DefaultMaxBodyBytes: 2 << 20,
+		MaxBodyBytes:        10 << 20,
+		DefaultLinkSample:   50,
+		MaxLinkSample:       500,
+	}
+}
+
+// resolve validates the options against the limits and the registered
+// strategy names, and fills in defaults.
+func (o Options) resolve(l Limits, knownStrategies []string) (Options, error) {
+	if o.Timeout < 0 {
+		return o, appErr.NewValidationError("timeout must not be negative")
+	}
+	if o.Timeout == 0 {
+		o.Timeout = l.DefaultTimeout
+	}
+	if l.MaxTimeout > 0 && o.Timeout > l.MaxTimeout {
+		return o, appErr.NewValidationError("timeout exceeds server maximum", fmt.Sprintf("max: %s", l.MaxTimeout))
+	}
+
+	if o.MaxBodyBytes < 0 {
+		return o, appErr.NewValidationError("max_body_bytes must not be negative")
+	}
+	if o.MaxBodyBytes == 0 {
+		o.MaxBodyBytes = l.DefaultMaxBodyBytes
+	}
+	if l.MaxBodyBytes > 0 && o.MaxBodyBytes > l.MaxBodyBytes {
+		return o, appErr.NewValidationError("max_body_bytes exceeds server maximum", fmt.Sprintf("max: %d", l.MaxBodyBytes))
+	}
+
+	o.UserAgent = strings.TrimSpace(o.UserAgent)
+	if o.UserAgent == "" {
+		o.UserAgent = factory.UserAgent
+	}
+	if !httpguts.ValidHeaderFieldValue(o.UserAgent) {
+		return o, appErr.NewValidationError("user_agent is not a valid header value", fmt.Sprintf("got: %q", o.UserAgent))
+	}
+
+	if len(o.Strategies) == 0 {
+		o.Strategies = knownStrategies
+	}
+	for _, name := range o.Strategies {
+		if !slices.Contains(knownStrategies, name) {
+			return o, appErr.NewValidationError("unknown strategy", fmt.Sprintf("got %q, expected one of %v", name, knownStrategies))
+		}
+	}
+
+	if o.LinkCheck == "" {
+		o.LinkCheck = l.DefaultLinkCheck
+	}
+	switch o.LinkCheck {
+	case "":
+		o.LinkCheck = LinkCheckFull
+	case LinkCheckFull, LinkCheckHead, LinkCheckSample, LinkCheckNone:
+	default:
+		return o, appErr.NewValidationError("unknown link check mode",
+			fmt.Sprintf("got %q, expected one of %v", o.LinkCheck, []string{LinkCheckFull, LinkCheckHead, LinkCheckSample, LinkCheckNone}))
+	}
+	if o.LinkSample < 0 {
+		return o, appErr.NewValidationError("link_sample must not be negative")
+	}
+	if o.LinkSample == 0 {
+		o.LinkSample = l.DefaultLinkSample
+	}
+	if l.MaxLinkSample > 0 && o.LinkSample > l.MaxLinkSample {
+		return o, appErr.NewValidationError("link_sample exceeds server maximum", fmt.Sprintf("max: %d", l.MaxLinkSample))
+	}
+	if o.LinkCheck == LinkCheckSample && o.LinkSample <= 0 {
+		return o, appErr.NewValidationError("link_sample must be positive in sample mode")
+	}
+
+	if o.Robots == "" {
+		o.Robots = l.DefaultRobots
+	}
+	switch o.Robots {
+	case "":
+		o.Robots = RobotsIgnore
+	case RobotsIgnore, RobotsReportOnly, RobotsObey:
+	default:
+		return o, appErr.NewValidationError("unknown robots policy", fmt.Sprintf("got %q, expected %q, %q or %q", o.Robots, RobotsIgnore, RobotsReportOnly, RobotsObey))
+	}
+
+	switch o.LinkScope {
+	case "":
+		o.LinkScope = LinkScopeHost
+	case LinkScopeHost, LinkScopeSite:
+	default:
+		return o, appErr.NewValidationError("unknown link scope", fmt.Sprintf("got %q, expected %q or %q", o.LinkScope, LinkScopeHost, LinkScopeSite))
+	}
+	return o, nil
+}
+
+func (o Options) wants(strategy string) bool {
+	return slices.Contains(o.Strategies, strategy)
+}
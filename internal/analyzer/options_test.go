@@ -0,0 +1,64 @@
+package analyzer
+
+import (
+	"testing"
+	"time"
+
+	"web-analyzer-go/internal/model"
+)
+
+var knownStrategies = New().strategyNames()
+
+func TestOptions_resolveDefaults(t *testing.T) {
+	l := DefaultLimits()
+	o, err := Options{}.resolve(l, knownStrategies)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if o.Timeout != l.DefaultTimeout || o.MaxBodyBytes != l.DefaultMaxBodyBytes {
+		t.Fatalf("expected server defaults, got %+v", o)
+	}
+	// Host scope keeps the link counts of callers that predate link_scope.
+	if o.UserAgent == "" || o.LinkCheck != LinkCheckFull || o.LinkScope != LinkScopeHost || len(o.Strategies) != len(knownStrategies) {
+		t.Fatalf("unexpected defaults: %+v", o)
+	}
+}
+
+func TestOptions_resolveRejects(t *testing.T) {
+	l := DefaultLimits()
+	cases := []struct {
+		name string
+		opts Options
+	}{
+		{"timeout over max", Options{Timeout: l.MaxTimeout + time.Second}},
+		{"negative timeout", Options{Timeout: -time.Second}},
+		{"body over max", Options{MaxBodyBytes: l.MaxBodyBytes + 1}},
+		{"link sample over max", Options{LinkCheck: LinkCheckSample, LinkSample: l.MaxLinkSample + 1}},
+		{"user agent with CRLF", Options{UserAgent: "bot\r\nX-Injected: 1"}},
+		{"user agent with control character", Options{UserAgent: "bot\x00"}},
+		{"unknown strategy", Options{Strategies: []string{"title", "nope"}}},
+		{"unknown link check", Options{LinkCheck: "sometimes"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := tc.opts.resolve(l, knownStrategies); err == nil {
+				t.Fatalf("expected validation error for %+v", tc.opts)
+			}
+		})
+	}
+}
+
+func Test_strategiesFor_selection(t *testing.T) {
+	o, _ := Options{Strategies: []string{StrategyTitle, StrategyLinks}, LinkCheck: LinkCheckNone}.resolve(DefaultLimits(), knownStrategies)
+	got, skipped := testAnalyzer.strategiesFor(o, nil)
+	if len(got) != 2 || len(skipped) != 4 {
+		t.Fatalf("expected 2 strategies and 4 skipped, got %d and %d", len(got), len(skipped))
+	}
+	links, ok := got[1].strategy.(*LinksStrategy)
+	if !ok || links.LinkChecker != nil {
+		t.Fatalf("expected links strategy without checker, got %#v", got[1])
+	}
+	if skipped[0].Name != StrategyHTMLVersion || skipped[0].Status != model.StrategySkipped {
+		t.Fatalf("unexpected skipped status: %+v", skipped[0])
+	}
+}
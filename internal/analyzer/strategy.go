@@ -1,7 +1,11 @@
 package analyzer
 
 import (
+	"context"
+	"fmt"
+	"net/http"
 	"net/url"
+	"time"
 	"web-analyzer-go/internal/factory"
 	"web-analyzer-go/internal/model"
 	"web-analyzer-go/internal/util"
@@ -9,10 +13,110 @@ import (
 	"golang.org/x/net/html"
 )
 
+// AnalyzerStrategy is the original, context-free strategy interface. Wrap
+// implementations with AdaptStrategy to run them.
 type AnalyzerStrategy interface {
 	Analyze(doc *html.Node, base *url.URL, result *model.AnalyzeResult) error
 }
 
+// ContextStrategy is the context-aware strategy interface the analyzer runs.
+// Implementations doing I/O must stop promptly once ctx is done.
+type ContextStrategy interface {
+	AnalyzeContext(ctx context.Context, doc *html.Node, base *url.URL, result *model.AnalyzeResult) error
+}
+
+// AdaptStrategy returns s as a ContextStrategy. Strategies that only implement
+// AnalyzerStrategy are skipped when ctx is already done but otherwise run to
+// completion.
+func AdaptStrategy(s AnalyzerStrategy) ContextStrategy {
+	if cs, ok := s.(ContextStrategy); ok {
+		return cs
+	}
+	return legacyStrategy{s}
+}
+
+type legacyStrategy struct {
+	AnalyzerStrategy
+}
+
+func (l legacyStrategy) AnalyzeContext(ctx context.Context, doc *html.Node, base *url.URL, result *model.AnalyzeResult) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return l.Analyze(doc, base, result)
+}
+
+// strategyTypeName names a strategy for logs and metrics, looking through adapters.
+func strategyTypeName(s ContextStrategy) string {
+	if l, ok := s.(legacyStrategy); ok {
+		return fmt.Sprintf("%T", l.AnalyzerStrategy)
+	}
+	return fmt.Sprintf("%T", s)
+}
+
+// StrategyDeps are the collaborators an analysis hands to its strategies.
+// LinkChecker and Fetcher are nil when link checking is disabled; Fetcher
+// retrieves further pages, such as link targets, under the same guards as
+// the analyzed page and the link checks' scheduling and robots.txt policy.
+type StrategyDeps struct {
+	LinkChecker factory.ContextLinkChecker
+	Fetcher     Fetcher
+	// Soft404 is set when the request asked for soft-404 detection.
+	Soft404 *factory.Soft404Detector
+	// Normalizer canonicalizes link URLs before they are deduplicated.
+	Normalizer util.URLNormalizer
+	// Page is the HTTP exchange that produced the document and Header its
+	// response headers, both nil when the document was submitted directly.
+	Page   *model.FetchInfo
+	Header http.Header
+}
+
+// StrategySpec registers a strategy under the name callers select it by in
+// Options.Strategies. New builds a fresh strategy for one analysis. Budget,
+// when positive, caps how long the strategy may run within the overall
+// request timeout.
+type StrategySpec struct {
+	Name   string
+	New    func(opts Options, deps StrategyDeps) ContextStrategy
+	Budget time.Duration
+}
+
+// DefaultStrategies returns the built-in strategies in execution order.
+func DefaultStrategies() []StrategySpec {
+	return []StrategySpec{
+		legacySpec(StrategyHTMLVersion, func() AnalyzerStrategy { return &HTMLVersionStrategy{} }),
+		legacySpec(StrategyTitle, func() AnalyzerStrategy { return &TitleStrategy{} }),
+		legacySpec(StrategyHeadings, func() AnalyzerStrategy { return &HeadingsStrategy{} }),
+		{Name: StrategyLinks, New: newLinksStrategy, Budget: 10 * time.Second},
+		legacySpec(StrategyLoginForm, func() AnalyzerStrategy { return &LoginFormStrategy{} }),
+		{Name: StrategySEO, New: newSEOStrategy},
+	}
+}
+
+// legacySpec registers a strategy that needs neither options nor a link checker.
+func legacySpec(name string, newStrategy func() AnalyzerStrategy) StrategySpec {
+	return StrategySpec{Name: name, New: func(Options, StrategyDeps) ContextStrategy {
+		return AdaptStrategy(newStrategy())
+	}}
+}
+
+func newLinksStrategy(opts Options, deps StrategyDeps) ContextStrategy {
+	return &LinksStrategy{
+		LinkChecker:  deps.LinkChecker,
+		Fetcher:      deps.Fetcher,
+		Soft404:      deps.Soft404,
+		Normalizer:   &deps.Normalizer,
+		Scope:        opts.LinkScope,
+		Sample:       sampleSize(opts),
+		UserAgent:    opts.UserAgent,
+		MaxBodyBytes: opts.MaxBodyBytes,
+	}
+}
+
+func newSEOStrategy(_ Options, deps StrategyDeps) ContextStrategy {
+	return AdaptStrategy(&SEOStrategy{Header: deps.Header, Normalizer: &deps.Normalizer})
+}
+
 type HTMLVersionStrategy struct{}
 
 func (s *HTMLVersionStrategy) Analyze(doc *html.Node, base *url.URL, result *model.AnalyzeResult) error {
@@ -34,14 +138,71 @@ func (s *HeadingsStrategy) Analyze(doc *html.Node, base *url.URL, result *model.
 	return nil
 }
 
+// LinksStrategy reports every unique link, grouped by kind, audits the
+// attributes of anchors, and when LinkChecker is set, probes each link for
+// accessibility, or with Sample set, that many randomly chosen links. A nil
+// LinkChecker classifies links without network calls. Checkers implementing
+// factory.LinkProber also contribute status codes, failure reasons and
+// redirect targets to the report. Fragments of same-page links are always
+// validated; with a Fetcher, internal pages linked with a fragment are
+// fetched, up to MaxBodyBytes, to validate theirs. With Soft404 set,
+// accessible anchors are examined for pages that only pretend to exist.
+// Link URLs are canonicalized by Normalizer, or util.DefaultURLNormalizer when
+// nil, before they are deduplicated. Scope is LinkScopeHost (the default) or
+// LinkScopeSite and decides which links count as internal.
 type LinksStrategy struct {
-	LinkChecker factory.LinkChecker
+	LinkChecker  factory.ContextLinkChecker
+	Fetcher      Fetcher
+	Soft404      *factory.Soft404Detector
+	Normalizer   *util.URLNormalizer
+	Scope        string
+	Sample       int
+	UserAgent    string
+	MaxBodyBytes int64
 }
 
 func (s *LinksStrategy) Analyze(doc *html.Node, base *url.URL, result *model.AnalyzeResult) error {
-	internal, external, inaccessible := util.CountLinks(doc, base, s.LinkChecker.IsAccessible)
-	result.Links = model.LinkStats{Internal: internal, External: external, Inaccessible: inaccessible}
-	return nil
+	return s.AnalyzeContext(context.Background(), doc, base, result)
+}
+
+// AnalyzeContext stops checking links once ctx is done, keeping the results
+// gathered so far, and returns the context's error.
+func (s *LinksStrategy) AnalyzeContext(ctx context.Context, doc *html.Node, base *url.URL, result *model.AnalyzeResult) error {
+	norm := util.DefaultURLNormalizer()
+	if s.Normalizer != nil {
+		norm = *s.Normalizer
+	}
+	links := util.ExtractLinksWith(doc, base, norm)
+	audit := util.AuditLinks(doc, base, norm)
+	result.LinkAudit = &audit
+	if s.Scope == LinkScopeSite {
+		for i := range links {
+			links[i].Internal = links[i].Site != model.SiteCrossSite
+		}
+	}
+	util.CheckSamePageFragments(doc, links)
+	var check func(context.Context, string) model.LinkCheck
+	if prober := factory.AdaptLinkProber(s.LinkChecker); prober != nil {
+		check = prober.CheckLink
+	}
+	var err error
+	if s.Sample > 0 && check != nil {
+		result.LinkSample, err = checkLinkSample(ctx, links, check, s.Sample)
+	} else {
+		err = util.CheckLinks(ctx, links, check)
+	}
+	if err == nil && s.Fetcher != nil {
+		err = s.checkPageFragments(ctx, links)
+	}
+	if err == nil && s.Soft404 != nil {
+		err = s.checkSoft404(ctx, links)
+	}
+	// On cancellation the report covers the links checked so far.
+	result.LinkDetails = links
+	result.LinkKinds = util.SummarizeLinksByKind(links)
+	result.Links = result.LinkKinds[model.LinkKindAnchor]
+	result.ExternalDomains = util.SummarizeExternalDomains(links)
+	return err
 }
 
 type LoginFormStrategy struct{}
@@ -50,3 +211,22 @@ func (s *LoginFormStrategy) Analyze(doc *html.Node, base *url.URL, result *model
 	result.LoginForm = util.HasLoginForm(doc)
 	return nil
 }
+
+// SEOStrategy reports the page's search-engine metadata and whether it can be
+// indexed. Header, when set, contributes the X-Robots-Tag header lines to the
+// verdict. The canonical link is compared with the page URL once
+// both are canonicalized by Normalizer, or util.DefaultURLNormalizer when nil.
+type SEOStrategy struct {
+	Header     http.Header
+	Normalizer *util.URLNormalizer
+}
+
+func (s *SEOStrategy) Analyze(doc *html.Node, base *url.URL, result *model.AnalyzeResult) error {
+	norm := util.DefaultURLNormalizer()
+	if s.Normalizer != nil {
+		norm = *s.Normalizer
+	}
+	seo := util.AnalyzeSEO(doc, base, norm, s.Header.Values("X-Robots-Tag"))
+	result.SEO = &seo
+	return nil
+}
@@ -1,16 +1,77 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"io"
+	"mime"
 	"net/http"
+	"time"
 	"web-analyzer-go/internal/analyzer"
 	appErr "web-analyzer-go/internal/errors"
+	"web-analyzer-go/internal/model"
 )
 
-var analyzePageFunc = analyzer.AnalyzePage
+// PageAnalyzer is the part of *analyzer.Analyzer the analyze endpoint uses.
+type PageAnalyzer interface {
+	AnalyzePage(ctx context.Context, targetURL string, opts analyzer.Options) (*model.AnalyzeResult, error)
+	AnalyzeHTML(ctx context.Context, body []byte, contentType, baseURL string, opts analyzer.Options) (*model.AnalyzeResult, error)
+}
+
+// AnalyzeHandler serves POST /analyze with an injected analyzer.
+type AnalyzeHandler struct {
+	analyzer PageAnalyzer
+}
+
+// NewAnalyzeHandler returns a handler that delegates analysis to a.
+func NewAnalyzeHandler(a PageAnalyzer) *AnalyzeHandler {
+	return &AnalyzeHandler{analyzer: a}
+}
 
 type analyzeRequest struct {
-	URL string `json:"url"`
+	URL     string          `json:"url"`
+	HTML    string          `json:"html,omitempty"`
+	BaseURL string          `json:"base_url,omitempty"`
+	Options *analyzeOptions `json:"options,omitempty"`
+
+	// htmlContentType is the Content-Type of an uploaded file part, used for charset detection.
+	htmlContentType string
+}
+
+// analyzeOptions are the optional per-request tuning knobs. Omitted fields use
+// the server defaults; values above the server maximums are rejected.
+type analyzeOptions struct {
+	TimeoutMs    int64    `json:"timeout_ms,omitempty"`
+	MaxBodyBytes int64    `json:"max_body_bytes,omitempty"`
+	UserAgent    string   `json:"user_agent,omitempty"`
+	Strategies   []string `json:"strategies,omitempty"`
+	LinkCheck    string   `json:"link_check,omitempty" enums:"full,head,sample,none"`
+	LinkSample   int      `json:"link_sample,omitempty"`
+	LinkScope    string   `json:"link_scope,omitempty" enums:"host,site"`
+	Strict       bool     `json:"strict,omitempty"`
+	Soft404      bool     `json:"soft_404,omitempty"`
+	SortQuery    bool     `json:"sort_query,omitempty"`
+	Robots       string   `json:"robots,omitempty" enums:"ignore,report_only,obey"`
+}
+
+func (o *analyzeOptions) toAnalyzerOptions() analyzer.Options {
+	if o == nil {
+		return analyzer.Options{}
+	}
+	return analyzer.Options{
+		Timeout:      time.Duration(o.TimeoutMs) * time.Millisecond,
+		MaxBodyBytes: o.MaxBodyBytes,
+		UserAgent:    o.UserAgent,
+		Strategies:   o.Strategies,
+		LinkCheck:    o.LinkCheck,
+		LinkSample:   o.LinkSample,
+		LinkScope:    o.LinkScope,
+		Strict:       o.Strict,
+		Soft404:      o.Soft404,
+		SortQuery:    o.SortQuery,
+		Robots:       o.Robots,
+	}
 }
 
 // errorResponse is deprecated, use appErr.HTTPResponse instead
@@ -19,37 +80,54 @@ type errorResponse struct {
 	StatusCode int    `json:"status_code"`
 }
 
-// AnalyzeHandler handles the analysis of a web page.
+// maxRequestBytes caps the request body. Raw HTML submissions need room for a
+// full document, which the analyzer then trims to its own body limit.
+const maxRequestBytes = 12 << 20
+
+// ServeHTTP handles the analysis of a web page.
 // @Summary Analyze a web page
 // @Description Analyzes the given URL and returns HTML version, title, headings, link stats, and login form presence.
+// @Description An optional options object tunes timeout, body size, User-Agent, strategies and link checking.
+// @Description Instead of a URL, raw HTML can be sent in the html field (with an optional base_url for resolving
+// @Description relative links) or uploaded as multipart/form-data in a "file" part. Link checking is off by default in that mode.
 // @Tags analyze
 // @Accept json
+// @Accept mpfd
 // @Produce json
-// @Param analyzeRequest body analyzeRequest true "URL to analyze"
+// @Param analyzeRequest body analyzeRequest true "URL or HTML to analyze"
 // @Success 200 {object} model.AnalyzeResult
 // @Failure 400 {object} errors.HTTPResponse
+// @Failure 403 {object} errors.HTTPResponse
+// @Failure 415 {object} errors.HTTPResponse
 // @Failure 502 {object} errors.HTTPResponse
 // @Router /analyze [post]
-func AnalyzeHandler(w http.ResponseWriter, r *http.Request) {
+func (h *AnalyzeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		appErr.HTTPErrorHandler(w, r, appErr.NewMethodNotAllowedError([]string{http.MethodPost}))
 		return
 	}
 
-	// Limit request body size to 1MB and ensure close
-	r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
+	// Limit request body size and ensure close
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBytes)
 	defer r.Body.Close()
 
-	var req analyzeRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		appErr.HTTPErrorHandler(w, r, appErr.NewBadRequestError("Invalid request body", err))
+	req, err := decodeAnalyzeRequest(r)
+	if err != nil {
+		appErr.HTTPErrorHandler(w, r, err)
 		return
 	}
-	if req.URL == "" {
-		appErr.HTTPErrorHandler(w, r, appErr.NewValidationError("URL is required"))
-		return
+
+	var result *model.AnalyzeResult
+	switch {
+	case req.URL != "" && req.HTML != "":
+		err = appErr.NewValidationError("provide either url or html, not both")
+	case req.HTML != "":
+		result, err = h.analyzer.AnalyzeHTML(r.Context(), []byte(req.HTML), req.htmlContentType, req.BaseURL, req.Options.toAnalyzerOptions())
+	case req.URL != "":
+		result, err = h.analyzer.AnalyzePage(r.Context(), req.URL, req.Options.toAnalyzerOptions())
+	default:
+		err = appErr.NewValidationError("URL is required", "send url, html or a multipart file upload")
 	}
-	result, err := analyzePageFunc(r.Context(), req.URL)
 	if err != nil {
 		appErr.HTTPErrorHandler(w, r, err)
 		return
@@ -57,3 +135,42 @@ func AnalyzeHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(result)
 }
+
+// decodeAnalyzeRequest reads either a JSON body or a multipart form whose
+// "file" part holds the HTML to analyze.
+func decodeAnalyzeRequest(r *http.Request) (*analyzeRequest, error) {
+	var req analyzeRequest
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return nil, appErr.NewBadRequestError("Invalid request body", err)
+		}
+		return &req, nil
+	}
+
+	if err := r.ParseMultipartForm(maxRequestBytes); err != nil {
+		return nil, appErr.NewBadRequestError("Invalid multipart body", err)
+	}
+	req.URL = r.FormValue("url")
+	req.BaseURL = r.FormValue("base_url")
+	if raw := r.FormValue("options"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &req.Options); err != nil {
+			return nil, appErr.NewBadRequestError("Invalid options field", err)
+		}
+	}
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		if errors.Is(err, http.ErrMissingFile) {
+			return &req, nil
+		}
+		return nil, appErr.NewBadRequestError("Invalid file upload", err)
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, appErr.NewBadRequestError("Invalid file upload", err)
+	}
+	req.HTML = string(data)
+	req.htmlContentType = header.Header.Get("Content-Type")
+	return &req, nil
+}
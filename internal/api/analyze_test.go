@@ -4,18 +4,36 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"net/textproto"
 	"testing"
+	"time"
 
 	"web-analyzer-go/internal/analyzer"
 	"web-analyzer-go/internal/model"
 )
 
+// fakeAnalyzer stubs the injected analyzer. Calling an unset func panics, flagging an unexpected call.
+type fakeAnalyzer struct {
+	page func(ctx context.Context, u string, opts analyzer.Options) (*model.AnalyzeResult, error)
+	html func(ctx context.Context, body []byte, contentType, baseURL string, opts analyzer.Options) (*model.AnalyzeResult, error)
+}
+
+func (f *fakeAnalyzer) AnalyzePage(ctx context.Context, u string, opts analyzer.Options) (*model.AnalyzeResult, error) {
+	return f.page(ctx, u, opts)
+}
+
+func (f *fakeAnalyzer) AnalyzeHTML(ctx context.Context, body []byte, contentType, baseURL string, opts analyzer.Options) (*model.AnalyzeResult, error) {
+	return f.html(ctx, body, contentType, baseURL, opts)
+}
+
 func TestAnalyzeHandler_MethodNotAllowed(t *testing.T) {
 	req := httptest.NewRequest(http.MethodGet, "/analyze", nil)
 	rr := httptest.NewRecorder()
-	AnalyzeHandler(rr, req)
+	h := NewAnalyzeHandler(&fakeAnalyzer{})
+	h.ServeHTTP(rr, req)
 	if rr.Code != http.StatusMethodNotAllowed {
 		t.Fatalf("expected 405, got %d", rr.Code)
 	}
@@ -24,55 +42,50 @@ func TestAnalyzeHandler_MethodNotAllowed(t *testing.T) {
 func TestAnalyzeHandler_BadRequestBody(t *testing.T) {
 	req := httptest.NewRequest(http.MethodPost, "/analyze", bytes.NewBufferString("{"))
 	rr := httptest.NewRecorder()
-	AnalyzeHandler(rr, req)
+	h := NewAnalyzeHandler(&fakeAnalyzer{})
+	h.ServeHTTP(rr, req)
 	if rr.Code != http.StatusBadRequest {
 		t.Fatalf("expected 400, got %d", rr.Code)
 	}
 }
 
 func TestAnalyzeHandler_InvalidURL(t *testing.T) {
-	old := analyzePageFunc
-	defer func() { analyzePageFunc = old }()
-	analyzePageFunc = func(ctx context.Context, u string) (*model.AnalyzeResult, error) {
+	h := NewAnalyzeHandler(&fakeAnalyzer{page: func(ctx context.Context, u string, _ analyzer.Options) (*model.AnalyzeResult, error) {
 		return nil, analyzer.ErrInvalidURL
-	}
+	}})
 	body, _ := json.Marshal(map[string]string{"url": ":bad:"})
 	req := httptest.NewRequest(http.MethodPost, "/analyze", bytes.NewReader(body))
 	req.Header.Set("Content-Type", "application/json")
 	rr := httptest.NewRecorder()
-	AnalyzeHandler(rr, req)
+	h.ServeHTTP(rr, req)
 	if rr.Code != http.StatusBadRequest {
 		t.Fatalf("expected 400, got %d", rr.Code)
 	}
 }
 
 func TestAnalyzeHandler_UpstreamError(t *testing.T) {
-	old := analyzePageFunc
-	defer func() { analyzePageFunc = old }()
-	analyzePageFunc = func(ctx context.Context, u string) (*model.AnalyzeResult, error) {
+	h := NewAnalyzeHandler(&fakeAnalyzer{page: func(ctx context.Context, u string, _ analyzer.Options) (*model.AnalyzeResult, error) {
 		return nil, analyzer.ErrUpstream
-	}
+	}})
 	body, _ := json.Marshal(map[string]string{"url": "http://simplewebapp.com"})
 	req := httptest.NewRequest(http.MethodPost, "/analyze", bytes.NewReader(body))
 	req.Header.Set("Content-Type", "application/json")
 	rr := httptest.NewRecorder()
-	AnalyzeHandler(rr, req)
+	h.ServeHTTP(rr, req)
 	if rr.Code != http.StatusBadGateway {
 		t.Fatalf("expected 502, got %d", rr.Code)
 	}
 }
 
 func TestAnalyzeHandler_Success(t *testing.T) {
-	old := analyzePageFunc
-	defer func() { analyzePageFunc = old }()
-	analyzePageFunc = func(ctx context.Context, u string) (*model.AnalyzeResult, error) {
+	h := NewAnalyzeHandler(&fakeAnalyzer{page: func(ctx context.Context, u string, _ analyzer.Options) (*model.AnalyzeResult, error) {
 		return &model.AnalyzeResult{Title: "ok"}, nil
-	}
+	}})
 	body, _ := json.Marshal(map[string]string{"url": "http://simplewebapp.com"})
 	req := httptest.NewRequest(http.MethodPost, "/analyze", bytes.NewReader(body))
 	req.Header.Set("Content-Type", "application/json")
 	rr := httptest.NewRecorder()
-	AnalyzeHandler(rr, req)
+	h.ServeHTTP(rr, req)
 	if rr.Code != http.StatusOK {
 		t.Fatalf("expected 200, got %d", rr.Code)
 	}
@@ -84,3 +97,93 @@ func TestAnalyzeHandler_Success(t *testing.T) {
 		t.Fatalf("unexpected title: %q", res.Title)
 	}
 }
+
+func TestAnalyzeHandler_PassesOptions(t *testing.T) {
+	var got analyzer.Options
+	h := NewAnalyzeHandler(&fakeAnalyzer{page: func(ctx context.Context, u string, opts analyzer.Options) (*model.AnalyzeResult, error) {
+		got = opts
+		return &model.AnalyzeResult{}, nil
+	}})
+	body := []byte(`{"url":"http://simplewebapp.com","options":{"timeout_ms":5000,"strategies":["title"],"link_check":"none"}}`)
+	req := httptest.NewRequest(http.MethodPost, "/analyze", bytes.NewReader(body))
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	if got.Timeout != 5*time.Second || got.LinkCheck != "none" || len(got.Strategies) != 1 {
+		t.Fatalf("unexpected options: %+v", got)
+	}
+}
+
+func TestAnalyzeHandler_InvalidUserAgent(t *testing.T) {
+	// The real analyzer rejects the option before anything is fetched.
+	h := NewAnalyzeHandler(analyzer.New())
+	body := []byte(`{"url":"http://simplewebapp.com","options":{"user_agent":"bot\r\nX-Injected: 1"}}`)
+	req := httptest.NewRequest(http.MethodPost, "/analyze", bytes.NewReader(body))
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestAnalyzeHandler_RawHTML(t *testing.T) {
+	var gotBody, gotBase string
+	h := NewAnalyzeHandler(&fakeAnalyzer{html: func(ctx context.Context, body []byte, contentType, baseURL string, _ analyzer.Options) (*model.AnalyzeResult, error) {
+		gotBody, gotBase = string(body), baseURL
+		return &model.AnalyzeResult{Title: "raw"}, nil
+	}})
+	body := []byte(`{"html":"<title>raw</title>","base_url":"https://site.example/"}`)
+	req := httptest.NewRequest(http.MethodPost, "/analyze", bytes.NewReader(body))
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	if gotBody != "<title>raw</title>" || gotBase != "https://site.example/" {
+		t.Fatalf("unexpected html/base: %q %q", gotBody, gotBase)
+	}
+}
+
+func TestAnalyzeHandler_MultipartUpload(t *testing.T) {
+	var gotBody, gotType string
+	var gotOpts analyzer.Options
+	h := NewAnalyzeHandler(&fakeAnalyzer{html: func(ctx context.Context, body []byte, contentType, baseURL string, opts analyzer.Options) (*model.AnalyzeResult, error) {
+		gotBody, gotType, gotOpts = string(body), contentType, opts
+		return &model.AnalyzeResult{}, nil
+	}})
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	_ = mw.WriteField("base_url", "https://site.example/")
+	_ = mw.WriteField("options", `{"link_check":"full"}`)
+	hdr := textproto.MIMEHeader{}
+	hdr.Set("Content-Disposition", `form-data; name="file"; filename="page.html"`)
+	hdr.Set("Content-Type", "text/html; charset=windows-1251")
+	part, _ := mw.CreatePart(hdr)
+	_, _ = part.Write([]byte("<title>upload</title>"))
+	_ = mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/analyze", &buf)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if gotBody != "<title>upload</title>" || gotType != "text/html; charset=windows-1251" || gotOpts.LinkCheck != "full" {
+		t.Fatalf("unexpected upload: %q %q %+v", gotBody, gotType, gotOpts)
+	}
+}
+
+func TestAnalyzeHandler_URLAndHTML(t *testing.T) {
+	body := []byte(`{"url":"http://simplewebapp.com","html":"<p>x</p>"}`)
+	req := httptest.NewRequest(http.MethodPost, "/analyze", bytes.NewReader(body))
+	rr := httptest.NewRecorder()
+	h := NewAnalyzeHandler(&fakeAnalyzer{})
+	h.ServeHTTP(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", rr.Code)
+	}
+}
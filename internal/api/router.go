@@ -11,10 +11,11 @@ import (
 	httpSwagger "github.com/swaggo/http-swagger"
 )
 
-func NewRouter() http.Handler {
+// NewRouter wires the HTTP endpoints around the injected analyzer.
+func NewRouter(a PageAnalyzer) http.Handler {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/health", service.HealthCheckHandler)
-	mux.HandleFunc("/analyze", AnalyzeHandler)
+	mux.Handle("/analyze", NewAnalyzeHandler(a))
 	mux.Handle("/metrics", metrics.Handler())
 	mux.Handle("/debug/pprof/", http.DefaultServeMux)
 	mux.Handle("/swagger/", httpSwagger.WrapHandler)
@@ -0,0 +1,147 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config holds server settings read from the environment at startup.
+type Config struct {
+	Addr string
+
+	// Analyze request limits. Defaults apply when a request does not set the
+	// option; maximums cap what a request may ask for.
+	DefaultTimeout      time.Duration
+	MaxTimeout          time.Duration
+	DefaultMaxBodyBytes int64
+	MaxBodyBytes        int64
+
+	// StrategyBudgets overrides per-strategy time budgets by strategy name,
+	// e.g. "links=8s,title=1s".
+	StrategyBudgets map[string]time.Duration
+
+	// LinkCheckMode is the default link-check mode (full, head, sample or
+	// none), LinkCheckSample the default sample size and LinkCheckMaxSample
+	// the largest one a request may ask for.
+	LinkCheckMode      string
+	LinkCheckSample    int
+	LinkCheckMaxSample int
+
+	// Link-check cache shared by all analyses. A size of 0 disables it.
+	LinkCachePositiveTTL time.Duration
+	LinkCacheNegativeTTL time.Duration
+	LinkCacheSize        int
+
+	// Link-check scheduling shared by all analyses; non-positive limits are off.
+	LinkCheckMaxInFlight int
+	LinkCheckPerHost     int
+	LinkCheckHostDelay   time.Duration
+
+	// Retries of transient link-check failures; 1 attempt disables retries.
+	LinkCheckMaxAttempts   int
+	LinkCheckMaxRetryAfter time.Duration
+
+	// LinkTrackingParams replaces the query parameters stripped from links
+	// before they are checked; nil keeps the built-in list.
+	LinkTrackingParams []string
+
+	// Robots is the default robots.txt policy (ignore, report_only or obey);
+	// parsed files are cached per origin for RobotsCacheTTL.
+	Robots         string
+	RobotsCacheTTL time.Duration
+
+	// Outbound connection guard overrides (comma-separated lists). Private,
+	// loopback and link-local addresses are blocked unless allowed here.
+	SSRFAllowCIDRs []string
+	SSRFDenyCIDRs  []string
+	SSRFAllowHosts []string
+	SSRFDenyHosts  []string
+}
+
+// Load reads the configuration from environment variables, falling back to
+// defaults for anything unset or malformed.
+func Load() Config {
+	return Config{
+		Addr:                   envString("ADDR", ":8080"),
+		DefaultTimeout:         envDuration("ANALYZER_TIMEOUT", 15*time.Second),
+		MaxTimeout:             envDuration("ANALYZER_MAX_TIMEOUT", 25*time.Second),
+		DefaultMaxBodyBytes:    envInt64("ANALYZER_BODY_BYTES", 2<<20),
+		MaxBodyBytes:           envInt64("ANALYZER_MAX_BODY_BYTES", 10<<20),
+		StrategyBudgets:        envDurationMap("ANALYZER_STRATEGY_BUDGETS"),
+		LinkCheckMode:          envString("LINK_CHECK_MODE", "full"),
+		LinkCheckSample:        int(envInt64("LINK_CHECK_SAMPLE_SIZE", 50)),
+		LinkCheckMaxSample:     int(envInt64("LINK_CHECK_MAX_SAMPLE_SIZE", 500)),
+		LinkCachePositiveTTL:   envDuration("LINK_CACHE_POSITIVE_TTL", 5*time.Minute),
+		LinkCacheNegativeTTL:   envDuration("LINK_CACHE_NEGATIVE_TTL", 30*time.Second),
+		LinkCacheSize:          int(envInt64("LINK_CACHE_SIZE", 10000)),
+		LinkCheckMaxInFlight:   int(envInt64("LINK_CHECK_MAX_IN_FLIGHT", 64)),
+		LinkCheckPerHost:       int(envInt64("LINK_CHECK_PER_HOST", 4)),
+		LinkCheckHostDelay:     envDuration("LINK_CHECK_HOST_DELAY", 0),
+		LinkCheckMaxAttempts:   int(envInt64("LINK_CHECK_MAX_ATTEMPTS", 3)),
+		LinkCheckMaxRetryAfter: envDuration("LINK_CHECK_MAX_RETRY_AFTER", 5*time.Second),
+		LinkTrackingParams:     envList("LINK_TRACKING_PARAMS"),
+		Robots:                 envString("ROBOTS_POLICY", "ignore"),
+		RobotsCacheTTL:         envDuration("ROBOTS_CACHE_TTL", time.Hour),
+		SSRFAllowCIDRs:         envList("SSRF_ALLOW_CIDRS"),
+		SSRFDenyCIDRs:          envList("SSRF_DENY_CIDRS"),
+		SSRFAllowHosts:         envList("SSRF_ALLOW_HOSTS"),
+		SSRFDenyHosts:          envList("SSRF_DENY_HOSTS"),
+	}
+}
+
+func envString(key, def string) string {
+	if v := strings.TrimSpace(os.Getenv(key)); v != "" {
+		return v
+	}
+	return def
+}
+
+func envList(key string) []string {
+	var out []string
+	for _, v := range strings.Split(os.Getenv(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func envDuration(key string, def time.Duration) time.Duration {
+	if v := strings.TrimSpace(os.Getenv(key)); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			return d
+		}
+	}
+	return def
+}
+
+// envDurationMap parses "name=duration" pairs; malformed pairs are ignored.
+func envDurationMap(key string) map[string]time.Duration {
+	var out map[string]time.Duration
+	for _, pair := range envList(key) {
+		name, v, ok := strings.Cut(pair, "=")
+		if !ok {
+			continue
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(v))
+		if err != nil {
+			continue
+		}
+		if out == nil {
+			out = make(map[string]time.Duration)
+		}
+		out[strings.TrimSpace(name)] = d
+	}
+	return out
+}
+
+func envInt64(key string, def int64) int64 {
+	if v := strings.TrimSpace(os.Getenv(key)); v != "" {
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return n
+		}
+	}
+	return def
+}
@@ -18,6 +18,7 @@ const (
 	ErrorTypeNotFound         ErrorType = "NOT_FOUND"
 	ErrorTypeMethodNotAllowed ErrorType = "METHOD_NOT_ALLOWED"
 	ErrorTypeTimeout          ErrorType = "TIMEOUT"
+	ErrorTypeUnsupportedMedia ErrorType = "UNSUPPORTED_MEDIA_TYPE"
 
 	// Server errors (5xx)
 	ErrorTypeInternal     ErrorType = "INTERNAL_ERROR"
@@ -92,6 +93,21 @@ func NewBadRequestError(message string, err error) *AppError {
 	}
 }
 
+// NewForbiddenError creates a new forbidden error
+func NewForbiddenError(message string, err error) *AppError {
+	det := ""
+	if err != nil {
+		det = err.Error()
+	}
+	return &AppError{
+		Type:       ErrorTypeForbidden,
+		Message:    message,
+		Details:    det,
+		StatusCode: http.StatusForbidden,
+		Err:        err,
+	}
+}
+
 // NewNotFoundError creates a new not found error
 func NewNotFoundError(resource string) *AppError {
 	return &AppError{
@@ -121,6 +137,16 @@ func NewTimeoutError(operation string, err error) *AppError {
 	}
 }
 
+// NewUnsupportedMediaTypeError creates a new unsupported media type error
+func NewUnsupportedMediaTypeError(mediaType string, details string) *AppError {
+	return &AppError{
+		Type:       ErrorTypeUnsupportedMedia,
+		Message:    fmt.Sprintf("unsupported media type %s", mediaType),
+		Details:    details,
+		StatusCode: http.StatusUnsupportedMediaType,
+	}
+}
+
 // NewInternalError creates a new internal server error
 func NewInternalError(message string, err error) *AppError {
 	return &AppError{
@@ -174,6 +200,8 @@ func WrapError(err error, errType ErrorType, message string) *AppError {
 		statusCode = http.StatusMethodNotAllowed
 	case ErrorTypeTimeout:
 		statusCode = http.StatusGatewayTimeout
+	case ErrorTypeUnsupportedMedia:
+		statusCode = http.StatusUnsupportedMediaType
 	case ErrorTypeUnavailable:
 		statusCode = http.StatusServiceUnavailable
 	case ErrorTypeUpstream:
@@ -0,0 +1,278 @@
+package factory
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+)
+
+// blockedNetworks are special-purpose ranges that are never fetched unless an
+// operator explicitly allows them. Loopback, RFC1918, link-local (which covers
+// cloud metadata at 169.254.169.254) and multicast are checked via net.IP helpers.
+// The 6to4 and NAT64 prefixes embed IPv4 addresses, private ones included.
+var blockedNetworks = mustParseCIDRs(
+	"0.0.0.0/8",
+	"100.64.0.0/10",
+	"192.0.0.0/24",
+	"192.0.2.0/24",
+	"198.18.0.0/15",
+	"198.51.100.0/24",
+	"203.0.113.0/24",
+	"240.0.0.0/4",
+	"64:ff9b::/96",
+	"64:ff9b:1::/48",
+	"100::/64",
+	"2001:db8::/32",
+	"2002::/16",
+)
+
+// BlockedError is returned when the dial guard refuses a connection.
+type BlockedError struct {
+	Host   string
+	IP     string
+	Reason string
+}
+
+func (e *BlockedError) Error() string {
+	if e.IP != "" {
+		return fmt.Sprintf("connection to %s (%s) blocked: %s", e.Host, e.IP, e.Reason)
+	}
+	return fmt.Sprintf("connection to %s blocked: %s", e.Host, e.Reason)
+}
+
+// DialGuardConfig lists operator overrides. Host entries match exactly or, when
+// they start with ".", any subdomain (".corp.example" matches "wiki.corp.example").
+// An allowed host may reach private ranges, but not addresses on the deny
+// list nor loopback, link-local, unspecified or multicast ones.
+type DialGuardConfig struct {
+	AllowCIDRs []string
+	DenyCIDRs  []string
+	AllowHosts []string
+	DenyHosts  []string
+}
+
+// DialGuard decides which addresses outbound connections may reach. Checks run
+// against the resolved IP at connect time, so DNS rebinding cannot slip a
+// private address past a hostname that looked public when the URL was parsed.
+// The zero value blocks the built-in ranges only. A guard is attached to the
+// clients built by DefaultHTTPClientFactory.
+type DialGuard struct {
+	allowNets  []*net.IPNet
+	denyNets   []*net.IPNet
+	allowHosts []string
+	denyHosts  []string
+}
+
+// NewDialGuard builds a guard from cfg, rejecting malformed CIDRs.
+func NewDialGuard(cfg DialGuardConfig) (*DialGuard, error) {
+	allow, err := parseCIDRs(cfg.AllowCIDRs)
+	if err != nil {
+		return nil, err
+	}
+	deny, err := parseCIDRs(cfg.DenyCIDRs)
+	if err != nil {
+		return nil, err
+	}
+	return &DialGuard{
+		allowNets:  allow,
+		denyNets:   deny,
+		allowHosts: normalizeHosts(cfg.AllowHosts),
+		denyHosts:  normalizeHosts(cfg.DenyHosts),
+	}, nil
+}
+
+// CheckHost applies the hostname allow/deny lists. It reports whether the host
+// is explicitly allowed, in which case its addresses are checked with
+// CheckAllowedIP rather than CheckIP.
+func (g *DialGuard) CheckHost(host string) (allowed bool, err error) {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if matchHost(g.denyHosts, host) {
+		return false, &BlockedError{Host: host, Reason: "host is on the deny list"}
+	}
+	return matchHost(g.allowHosts, host), nil
+}
+
+// CheckIP applies the CIDR allow/deny lists and the built-in private ranges.
+func (g *DialGuard) CheckIP(host string, ip net.IP) error {
+	return g.checkIP(host, ip, isInternalIP)
+}
+
+// CheckAllowedIP is CheckIP for a host on the allow list: the deny list still
+// applies, and of the built-in ranges only loopback, link-local, unspecified
+// and multicast addresses are refused.
+func (g *DialGuard) CheckAllowedIP(host string, ip net.IP) error {
+	return g.checkIP(host, ip, isLocalIP)
+}
+
+func (g *DialGuard) checkIP(host string, ip net.IP, blocked func(net.IP) bool) error {
+	for _, n := range g.denyNets {
+		if n.Contains(ip) {
+			return &BlockedError{Host: host, IP: ip.String(), Reason: "address is on the deny list"}
+		}
+	}
+	for _, n := range g.allowNets {
+		if n.Contains(ip) {
+			return nil
+		}
+	}
+	if blocked(ip) {
+		return &BlockedError{Host: host, IP: ip.String(), Reason: "address is private, loopback or link-local"}
+	}
+	return nil
+}
+
+// checkHostIP checks ip, one of host's addresses, according to whether the
+// host is on the allow list.
+func (g *DialGuard) checkHostIP(host string, ip net.IP, hostAllowed bool) error {
+	if hostAllowed {
+		return g.CheckAllowedIP(host, ip)
+	}
+	return g.CheckIP(host, ip)
+}
+
+// guardedDialContext wraps dialer so every connection passes g.
+func guardedDialContext(g *DialGuard, dialer *net.Dialer) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		allowed, err := g.CheckHost(host)
+		if err != nil {
+			return nil, err
+		}
+		d := *dialer
+		d.Control = func(_, address string, _ syscall.RawConn) error {
+			ipStr, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(ipStr)
+			if ip == nil {
+				return &BlockedError{Host: host, IP: ipStr, Reason: "unparseable address"}
+			}
+			return g.checkHostIP(host, ip, allowed)
+		}
+		return d.DialContext(ctx, network, addr)
+	}
+}
+
+// CheckTarget applies the guard to host before a request is handed to a
+// proxy, which makes the connection itself. Host names are resolved here and
+// every address must pass; one that cannot be resolved is refused. The proxy
+// resolves the name again, so unlike direct dials this cannot rule out DNS
+// rebinding between the two lookups.
+func (g *DialGuard) CheckTarget(ctx context.Context, host string) error {
+	allowed, err := g.CheckHost(host)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return g.checkHostIP(host, ip, allowed)
+	}
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return &BlockedError{Host: host, Reason: "target could not be resolved for the proxy check"}
+	}
+	for _, a := range addrs {
+		if err := g.checkHostIP(host, a.IP, allowed); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// guardedProxy wraps proxy so that requests it routes through a proxy are
+// checked with g.CheckTarget; direct requests are left to the dial guard.
+func guardedProxy(g *DialGuard, proxy func(*http.Request) (*url.URL, error)) func(*http.Request) (*url.URL, error) {
+	if proxy == nil {
+		return nil
+	}
+	return func(req *http.Request) (*url.URL, error) {
+		u, err := proxy(req)
+		if err != nil || u == nil {
+			return u, err
+		}
+		if err := g.CheckTarget(req.Context(), req.URL.Hostname()); err != nil {
+			return nil, err
+		}
+		return u, nil
+	}
+}
+
+func isInternalIP(ip net.IP) bool {
+	if isLocalIP(ip) || ip.IsPrivate() {
+		return true
+	}
+	for _, n := range blockedNetworks {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// isLocalIP reports addresses no host may be allowed to reach: loopback,
+// link-local (cloud metadata), unspecified and multicast.
+func isLocalIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast()
+}
+
+func matchHost(patterns []string, host string) bool {
+	for _, p := range patterns {
+		if p == host || (strings.HasPrefix(p, ".") && strings.HasSuffix(host, p)) {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeHosts(hosts []string) []string {
+	out := make([]string, 0, len(hosts))
+	for _, h := range hosts {
+		h = strings.ToLower(strings.TrimSpace(h))
+		h = strings.TrimPrefix(h, "*")
+		if h != "" {
+			out = append(out, h)
+		}
+	}
+	return out
+}
+
+func parseCIDRs(cidrs []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(cidrs))
+	for _, c := range cidrs {
+		c = strings.TrimSpace(c)
+		if c == "" {
+			continue
+		}
+		if !strings.Contains(c, "/") {
+			// Bare addresses are treated as single-host networks.
+			if ip := net.ParseIP(c); ip != nil && ip.To4() != nil {
+				c += "/32"
+			} else {
+				c += "/128"
+			}
+		}
+		_, n, err := net.ParseCIDR(c)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q: %w", c, err)
+		}
+		nets = append(nets, n)
+	}
+	return nets, nil
+}
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	nets, err := parseCIDRs(cidrs)
+	if err != nil {
+		panic(err)
+	}
+	return nets
+}
@@ -0,0 +1,133 @@
+package factory
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+)
+
+func TestDialGuard_CheckIP(t *testing.T) {
+	g, err := NewDialGuard(DialGuardConfig{
+		AllowCIDRs: []string{"10.1.0.0/16"},
+		DenyCIDRs:  []string{"93.184.216.0/24"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cases := []struct {
+		ip      string
+		blocked bool
+	}{
+		{"127.0.0.1", true},
+		{"169.254.169.254", true},
+		{"192.168.1.10", true},
+		{"10.2.3.4", true},
+		{"10.1.3.4", false},
+		{"::1", true},
+		{"fd00::1", true},
+		{"93.184.216.34", true},
+		{"2002:c0a8:101::1", true},
+		{"64:ff9b::a9fe:a9fe", true},
+		{"8.8.8.8", false},
+	}
+	for _, tc := range cases {
+		err := g.CheckIP("host", net.ParseIP(tc.ip))
+		if (err != nil) != tc.blocked {
+			t.Errorf("%s: blocked=%v, err=%v", tc.ip, tc.blocked, err)
+		}
+	}
+}
+
+func TestDialGuard_CheckHost(t *testing.T) {
+	g, _ := NewDialGuard(DialGuardConfig{
+		AllowHosts: []string{"*.corp.example"},
+		DenyHosts:  []string{"metadata.google.internal"},
+	})
+	if allowed, err := g.CheckHost("wiki.corp.example"); !allowed || err != nil {
+		t.Errorf("expected subdomain to be allowed, got %v %v", allowed, err)
+	}
+	if _, err := g.CheckHost("metadata.google.internal."); err == nil {
+		t.Errorf("expected denied host to be blocked")
+	}
+	if allowed, err := g.CheckHost("example.com"); allowed || err != nil {
+		t.Errorf("expected unlisted host to fall through to IP checks, got %v %v", allowed, err)
+	}
+}
+
+func TestDialGuard_allowedHostAddresses(t *testing.T) {
+	g, _ := NewDialGuard(DialGuardConfig{
+		AllowHosts: []string{".corp.example"},
+		DenyCIDRs:  []string{"10.9.0.0/16"},
+	})
+	cases := []struct {
+		ip      string
+		blocked bool
+	}{
+		{"10.1.2.3", false},
+		{"10.9.1.1", true},
+		{"127.0.0.1", true},
+		{"169.254.169.254", true},
+	}
+	for _, tc := range cases {
+		err := g.CheckAllowedIP("wiki.corp.example", net.ParseIP(tc.ip))
+		if (err != nil) != tc.blocked {
+			t.Errorf("%s: blocked=%v, err=%v", tc.ip, tc.blocked, err)
+		}
+	}
+
+	// An allow-listed name that resolves to loopback is still refused.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("request should not reach a loopback server")
+	}))
+	defer srv.Close()
+	local, _ := NewDialGuard(DialGuardConfig{AllowHosts: []string{"localhost"}})
+	_, port, _ := net.SplitHostPort(srv.Listener.Addr().String())
+	_, err := newGuardedClient(local).Get("http://localhost:" + port + "/")
+	var blocked *BlockedError
+	if !errors.As(err, &blocked) {
+		t.Fatalf("expected an allowed host on loopback to be blocked, got %v", err)
+	}
+}
+
+func TestNewDialGuard_InvalidCIDR(t *testing.T) {
+	if _, err := NewDialGuard(DialGuardConfig{DenyCIDRs: []string{"10.0.0.0/99"}}); err == nil {
+		t.Fatalf("expected error for invalid CIDR")
+	}
+}
+
+func TestGuardedTransport_checksTargetBeforeProxy(t *testing.T) {
+	var proxied atomic.Int32
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied.Add(1)
+	}))
+	defer proxy.Close()
+	proxyURL, _ := url.Parse(proxy.URL)
+
+	// The proxy itself is on loopback, so allow that; targets are checked on their own.
+	g, _ := NewDialGuard(DialGuardConfig{AllowCIDRs: []string{"127.0.0.0/8", "10.1.0.0/16"}})
+	client := &http.Client{Transport: newGuardedTransport(g, http.ProxyURL(proxyURL))}
+
+	for _, target := range []string{"http://169.254.169.254/latest/meta-data/", "http://192.168.1.1/"} {
+		_, err := client.Get(target)
+		var blocked *BlockedError
+		if !errors.As(err, &blocked) {
+			t.Errorf("%s: expected the target to be blocked, got %v", target, err)
+		}
+	}
+	if n := proxied.Load(); n != 0 {
+		t.Fatalf("blocked targets reached the proxy %d times", n)
+	}
+
+	resp, err := client.Get("http://10.1.2.3/")
+	if err != nil {
+		t.Fatalf("expected an allowed target to go through the proxy, got %v", err)
+	}
+	resp.Body.Close()
+	if proxied.Load() != 1 {
+		t.Fatalf("expected one proxied request, got %d", proxied.Load())
+	}
+}
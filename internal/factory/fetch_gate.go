@@ -0,0 +1,45 @@
+package factory
+
+import (
+	"context"
+	"errors"
+	"web-analyzer-go/internal/metrics"
+)
+
+// ErrRobotsDisallowed is returned by FetchGate.Do for a link robots.txt
+// disallows when the gate obeys it.
+var ErrRobotsDisallowed = errors.New("disallowed by robots.txt")
+
+// FetchGate admits page fetches of link targets, such as those validating
+// fragments or looking for soft 404s, under the same per-host and global
+// limits and robots.txt policy as link checks. Nil fields impose nothing, and
+// a nil gate admits every fetch.
+type FetchGate struct {
+	Scheduler *LinkScheduler
+	Robots    *RobotsCache
+	// Obey refuses links Robots disallows.
+	Obey bool
+	// Metrics receives the scheduler's queue changes; nil means Prometheus.
+	Metrics metrics.Sink
+}
+
+// Do runs fetch once link may be requested and returns its error. It fails
+// with ErrRobotsDisallowed, or the context's error while queued, without
+// running fetch.
+func (g *FetchGate) Do(ctx context.Context, link string, fetch func() error) error {
+	if g == nil {
+		return fetch()
+	}
+	if g.Obey && g.Robots != nil && !g.Robots.Allowed(ctx, link) {
+		return ErrRobotsDisallowed
+	}
+	if g.Scheduler == nil {
+		return fetch()
+	}
+	release, err := g.Scheduler.acquire(ctx, linkHost(link), metrics.OrDefault(g.Metrics))
+	if err != nil {
+		return err
+	}
+	defer release()
+	return fetch()
+}
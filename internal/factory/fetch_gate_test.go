@@ -0,0 +1,63 @@
+package factory
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFetchGate(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/robots.txt", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("User-agent: *\nDisallow: /private\n"))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	robots := NewRobotsCache(srv.Client(), DefaultRobotsConfig())
+
+	var fetched atomic.Int32
+	fetch := func() error { fetched.Add(1); return nil }
+	obey := &FetchGate{Robots: robots, Obey: true}
+	if err := obey.Do(context.Background(), srv.URL+"/private/page", fetch); !errors.Is(err, ErrRobotsDisallowed) || fetched.Load() != 0 {
+		t.Fatalf("expected a disallowed fetch to be refused, got %v after %d fetches", err, fetched.Load())
+	}
+	if err := obey.Do(context.Background(), srv.URL+"/public", fetch); err != nil || fetched.Load() != 1 {
+		t.Fatalf("expected an allowed fetch to run, got %v", err)
+	}
+	report := &FetchGate{Robots: robots}
+	if err := report.Do(context.Background(), srv.URL+"/private/page", fetch); err != nil || fetched.Load() != 2 {
+		t.Fatalf("expected a gate not obeying robots.txt to fetch, got %v", err)
+	}
+
+	// Fetches share the scheduler's per-host limit.
+	gate := &FetchGate{Scheduler: NewLinkScheduler(LinkSchedulerConfig{PerHostLimit: 1})}
+	var inFlight, peak atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			gate.Do(context.Background(), "http://a.test/page", func() error {
+				n := inFlight.Add(1)
+				for {
+					p := peak.Load()
+					if n <= p || peak.CompareAndSwap(p, n) {
+						break
+					}
+				}
+				time.Sleep(5 * time.Millisecond)
+				inFlight.Add(-1)
+				return nil
+			})
+		}()
+	}
+	wg.Wait()
+	if peak.Load() != 1 {
+		t.Fatalf("expected at most one fetch per host at a time, got %d", peak.Load())
+	}
+}
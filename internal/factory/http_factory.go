@@ -4,8 +4,10 @@ import (
 	"context"
 	"net"
 	"net/http"
+	"net/url"
 	"sync"
 	"time"
+	"web-analyzer-go/internal/model"
 )
 
 const UserAgent = "web-analyzer-go/1.0"
@@ -14,10 +16,18 @@ type HTTPClientFactory interface {
 	NewClient() *http.Client
 }
 
-type DefaultHTTPClientFactory struct{}
+// DefaultHTTPClientFactory builds clients whose connections pass Guard, or
+// a zero DialGuard when Guard is nil. Without a Guard every call returns the
+// same process-wide client.
+type DefaultHTTPClientFactory struct {
+	Guard *DialGuard
+}
 
 func (f *DefaultHTTPClientFactory) NewClient() *http.Client {
-	return getSharedHTTPClient()
+	if f.Guard == nil {
+		return getSharedHTTPClient()
+	}
+	return newGuardedClient(f.Guard)
 }
 
 var (
@@ -25,76 +35,239 @@ var (
 	sharedClientOnce sync.Once
 )
 
+// newGuardedTransport returns the transport behind the factory's clients.
+// Direct connections pass g; requests sent through a proxy from proxy have
+// their target checked first, since the dial guard only sees the proxy.
+func newGuardedTransport(g *DialGuard, proxy func(*http.Request) (*url.URL, error)) *http.Transport {
+	return &http.Transport{
+		Proxy: guardedProxy(g, proxy),
+		DialContext: guardedDialContext(g, &net.Dialer{
+			Timeout:   5 * time.Second,
+			KeepAlive: 120 * time.Second,
+		}),
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          200,
+		MaxIdleConnsPerHost:   50,
+		MaxConnsPerHost:       100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+		ResponseHeaderTimeout: 10 * time.Second,
+	}
+}
+
 func getSharedHTTPClient() *http.Client {
 	sharedClientOnce.Do(func() {
-		transport := &http.Transport{
-			Proxy: http.ProxyFromEnvironment,
-			DialContext: (&net.Dialer{
-				Timeout:   5 * time.Second,
-				KeepAlive: 120 * time.Second,
-			}).DialContext,
-			ForceAttemptHTTP2:     true,
-			MaxIdleConns:          200,
-			MaxIdleConnsPerHost:   50,
-			MaxConnsPerHost:       100,
-			IdleConnTimeout:       90 * time.Second,
-			TLSHandshakeTimeout:   10 * time.Second,
-			ExpectContinueTimeout: 1 * time.Second,
-			ResponseHeaderTimeout: 10 * time.Second,
-		}
-		sharedClient = &http.Client{
-			Timeout:   15 * time.Second,
-			Transport: transport,
-			CheckRedirect: func(req *http.Request, via []*http.Request) error {
-				if len(via) >= 10 {
-					return http.ErrUseLastResponse
-				}
-				return nil
-			},
-		}
+		sharedClient = newGuardedClient(&DialGuard{})
 	})
 	return sharedClient
 }
 
+func newGuardedClient(g *DialGuard) *http.Client {
+	// No overall Timeout: the request context carries the deadline chosen
+	// per analysis, which may exceed any fixed value here.
+	return &http.Client{
+		Transport: newGuardedTransport(g, http.ProxyFromEnvironment),
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 10 {
+				return http.ErrUseLastResponse
+			}
+			return nil
+		},
+	}
+}
+
 type LinkChecker interface {
 	IsAccessible(link string) bool
 }
 
+// ContextLinkChecker is the context-aware form of LinkChecker. Implementations
+// must give up as soon as ctx is done and report the link as inaccessible.
+type ContextLinkChecker interface {
+	IsAccessibleContext(ctx context.Context, link string) bool
+}
+
+// AdaptLinkChecker returns c as a ContextLinkChecker. Checkers that only
+// implement LinkChecker are wrapped so they are skipped once ctx is done,
+// although an individual in-flight check cannot be interrupted.
+func AdaptLinkChecker(c LinkChecker) ContextLinkChecker {
+	if c == nil {
+		return nil
+	}
+	if cc, ok := c.(ContextLinkChecker); ok {
+		return cc
+	}
+	return legacyLinkChecker{c}
+}
+
+type legacyLinkChecker struct {
+	LinkChecker
+}
+
+func (l legacyLinkChecker) IsAccessibleContext(ctx context.Context, link string) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	return l.IsAccessible(link)
+}
+
+// LinkProber is a link checker that reports how each check went rather than
+// just whether the link is accessible: a category, the status code, the
+// error text and whether the response looked like bot protection.
+type LinkProber interface {
+	CheckLink(ctx context.Context, link string) model.LinkCheck
+}
+
+// AdaptLinkProber returns c as a LinkProber. Checkers that only answer
+// accessible or not are wrapped and report just that, the latency and, for
+// accessible links, the ok category.
+func AdaptLinkProber(c ContextLinkChecker) LinkProber {
+	if c == nil {
+		return nil
+	}
+	if p, ok := c.(LinkProber); ok {
+		return p
+	}
+	return boolLinkProber{c}
+}
+
+type boolLinkProber struct {
+	ContextLinkChecker
+}
+
+func (b boolLinkProber) CheckLink(ctx context.Context, link string) model.LinkCheck {
+	start := time.Now()
+	result := model.LinkCheck{Accessible: b.IsAccessibleContext(ctx, link)}
+	if result.Accessible {
+		result.Category = model.LinkOK
+	}
+	result.LatencyMs = time.Since(start).Milliseconds()
+	return result
+}
+
 type DefaultLinkChecker struct {
 	Client *http.Client
+	// UserAgent overrides the default User-Agent header when set.
+	UserAgent string
+	// Retry bounds retries of transient failures; the zero value uses
+	// DefaultRetryPolicy.
+	Retry RetryPolicy
+	// HeadOnly skips the ranged GET fallback, so links on servers that
+	// reject HEAD are reported as they answered it.
+	HeadOnly bool
+}
+
+func (c *DefaultLinkChecker) userAgent() string {
+	return userAgentOrDefault(c.UserAgent)
+}
+
+// userAgentOrDefault returns ua, or the package UserAgent when ua is empty.
+func userAgentOrDefault(ua string) string {
+	if ua != "" {
+		return ua
+	}
+	return UserAgent
 }
 
+// IsAccessible checks link with a background context.
 func (c *DefaultLinkChecker) IsAccessible(link string) bool {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+	return c.IsAccessibleContext(context.Background(), link)
+}
 
-	// Try HEAD first with User-Agent
-	req, err := http.NewRequestWithContext(ctx, http.MethodHead, link, nil)
-	if err == nil {
-		req.Header.Set("User-Agent", UserAgent)
-		if resp, doErr := c.Client.Do(req); doErr == nil {
-			defer resp.Body.Close()
-			if resp.StatusCode >= 200 && resp.StatusCode < 400 {
-				return true
-			}
-			// If HEAD not supported, fall through to GET
-			if resp.StatusCode != http.StatusMethodNotAllowed && resp.StatusCode != http.StatusNotImplemented {
-				return false
+// IsAccessibleContext reports whether CheckLink found link accessible.
+func (c *DefaultLinkChecker) IsAccessibleContext(ctx context.Context, link string) bool {
+	return c.CheckLink(ctx, link).Accessible
+}
+
+// CheckLink probes link with HEAD, falling back to a ranged GET when HEAD is
+// not supported unless HeadOnly is set, and retries transient failures
+// according to c.Retry. Each attempt is capped at 5 seconds within ctx; the
+// reported latency covers all attempts and the waits between them.
+func (c *DefaultLinkChecker) CheckLink(ctx context.Context, link string) model.LinkCheck {
+	policy := c.Retry.orDefault()
+	start := time.Now()
+	var result model.LinkCheck
+	for attempt := 1; ; attempt++ {
+		var header http.Header
+		var err error
+		result, header, err = c.probe(ctx, link)
+		result.Attempts = attempt
+		transient := isTransientStatus(result.StatusCode)
+		if err != nil {
+			transient = isTransientError(err)
+		}
+		if !transient || attempt >= policy.MaxAttempts {
+			break
+		}
+		wait := policy.backoff(attempt)
+		if d, ok := parseRetryAfter(header.Get("Retry-After"), time.Now()); ok {
+			if d > policy.MaxRetryAfter {
+				break
 			}
+			wait = d
 		}
+		if sleepContext(ctx, wait) != nil {
+			break
+		}
+	}
+	result.LatencyMs = time.Since(start).Milliseconds()
+	return result
+}
+
+// probe makes one attempt at link. The response header is returned so the
+// caller can honour Retry-After; err is the transport error, if any.
+func (c *DefaultLinkChecker) probe(ctx context.Context, link string) (model.LinkCheck, http.Header, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Try HEAD first with User-Agent
+	result, header, err := c.do(ctx, http.MethodHead, link)
+	if c.HeadOnly && err != nil {
+		return model.LinkCheck{Category: errorCategory(err), Error: err.Error()}, nil, err
+	}
+	// If HEAD not supported, fall through to GET
+	if c.HeadOnly || err == nil && result.StatusCode != http.StatusMethodNotAllowed && result.StatusCode != http.StatusNotImplemented {
+		return result, header, nil
 	}
 
 	// Fallback: GET with Range to minimize payload
-	reqGet, err2 := http.NewRequestWithContext(ctx, http.MethodGet, link, nil)
-	if err2 != nil {
-		return false
+	result, header, err = c.do(ctx, http.MethodGet, link)
+	if err != nil {
+		return model.LinkCheck{Category: errorCategory(err), Error: err.Error()}, nil, err
 	}
-	reqGet.Header.Set("User-Agent", UserAgent)
-	reqGet.Header.Set("Range", "bytes=0-0")
-	respGet, errDo := c.Client.Do(reqGet)
-	if errDo != nil {
-		return false
+	return result, header, nil
+}
+
+// do sends one probe request and describes the response.
+func (c *DefaultLinkChecker) do(ctx context.Context, method, link string) (model.LinkCheck, http.Header, error) {
+	req, err := http.NewRequestWithContext(ctx, method, link, nil)
+	if err != nil {
+		return model.LinkCheck{}, nil, err
+	}
+	req.Header.Set("User-Agent", c.userAgent())
+	if method == http.MethodGet {
+		req.Header.Set("Range", "bytes=0-0")
+	}
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return model.LinkCheck{}, nil, err
+	}
+	defer resp.Body.Close()
+
+	result := model.LinkCheck{
+		Accessible: resp.StatusCode >= 200 && resp.StatusCode < 400,
+		StatusCode: resp.StatusCode,
+	}
+	if final := resp.Request.URL.String(); final != link {
+		result.RedirectURL = final
+	} else if loc := resp.Header.Get("Location"); loc != "" {
+		// Redirect not followed, e.g. the hop limit was reached.
+		result.RedirectURL = loc
+	}
+	result.Category = statusCategory(resp.StatusCode, result.RedirectURL != "")
+	if !result.Accessible {
+		result.Error = resp.Status
+		result.Blocked = isBotProtection(resp.StatusCode, resp.Header)
 	}
-	defer respGet.Body.Close()
-	return respGet.StatusCode >= 200 && respGet.StatusCode < 400
+	return result, resp.Header, nil
 }
@@ -0,0 +1,221 @@
+package factory
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+	"web-analyzer-go/internal/metrics"
+	"web-analyzer-go/internal/model"
+)
+
+// LinkCacheConfig bounds the link-check cache. Accessible results are kept
+// for PositiveTTL and inaccessible ones for NegativeTTL; a non-positive TTL
+// disables caching of that outcome. MaxEntries caps the number of cached
+// URLs, evicting the least recently used.
+type LinkCacheConfig struct {
+	PositiveTTL time.Duration
+	NegativeTTL time.Duration
+	MaxEntries  int
+}
+
+// DefaultLinkCacheConfig returns the limits used when none are configured.
+func DefaultLinkCacheConfig() LinkCacheConfig {
+	return LinkCacheConfig{
+		PositiveTTL: 5 * time.Minute,
+		NegativeTTL: 30 * time.Second,
+		MaxEntries:  10000,
+	}
+}
+
+// LinkCache memoizes link-check results across analyses. Concurrent checks of
+// the same URL are coalesced into a single upstream request. Results are
+// keyed by namespace, User-Agent and URL, since servers may answer user
+// agents differently. A LinkCache is safe for concurrent use.
+type LinkCache struct {
+	cfg LinkCacheConfig
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List // front is most recently used
+	calls   map[string]*linkCall
+}
+
+// linkCall is a check in flight, shared by its waiters. It is cancelled
+// once the last of them has given up.
+type linkCall struct {
+	done    chan struct{}
+	result  model.LinkCheck
+	waiters int
+	cancel  context.CancelFunc
+}
+
+type linkCacheEntry struct {
+	key     string
+	result  model.LinkCheck
+	expires time.Time
+}
+
+// NewLinkCache returns an empty cache with the given limits.
+func NewLinkCache(cfg LinkCacheConfig) *LinkCache {
+	return &LinkCache{
+		cfg:     cfg,
+		now:     time.Now,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+		calls:   make(map[string]*linkCall),
+	}
+}
+
+// Wrap returns a checker that consults the cache before delegating to next.
+// Lookups are counted in the Prometheus metrics.
+func (c *LinkCache) Wrap(next ContextLinkChecker) ContextLinkChecker {
+	return c.WrapNamespace(next, "", "", nil)
+}
+
+// WrapNamespace is Wrap for a checker whose results must not be shared with
+// checkers probing differently, such as a HEAD-only one, or sending another
+// userAgent; results are cached apart per namespace and User-Agent. Lookups
+// are reported to sink, or to Prometheus when nil.
+func (c *LinkCache) WrapNamespace(next ContextLinkChecker, namespace, userAgent string, sink metrics.Sink) ContextLinkChecker {
+	if c == nil || next == nil {
+		return next
+	}
+	return &cachedLinkChecker{
+		cache:   c,
+		next:    AdaptLinkProber(next),
+		scope:   namespace + "\n" + userAgent + "\n",
+		metrics: metrics.OrDefault(sink),
+	}
+}
+
+// Len reports the number of cached URLs, including expired ones not yet evicted.
+func (c *LinkCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+// check returns the cached result for link in scope or runs next once for
+// all concurrent callers. The shared check outlives any single caller so one
+// analysis giving up does not fail the others, but it is cancelled, and its
+// result not cached, once every caller has given up. A caller whose ctx is
+// done stops waiting and gets an inaccessible result.
+func (c *LinkCache) check(ctx context.Context, scope, link string, next LinkProber, sink metrics.Sink) model.LinkCheck {
+	key := scope + link
+	if result, ok := c.get(key); ok {
+		sink.ObserveLinkCacheLookup(true)
+		return result
+	}
+	sink.ObserveLinkCacheLookup(false)
+
+	c.mu.Lock()
+	call, ok := c.calls[key]
+	if !ok {
+		callCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		call = &linkCall{done: make(chan struct{}), cancel: cancel}
+		c.calls[key] = call
+		go c.run(callCtx, key, link, next, call)
+	}
+	call.waiters++
+	c.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.result
+	case <-ctx.Done():
+		c.leave(key, call)
+		return model.LinkCheck{Category: errorCategory(ctx.Err()), Error: ctx.Err().Error()}
+	}
+}
+
+// run performs a shared check and publishes its result to the waiters.
+func (c *LinkCache) run(ctx context.Context, key, link string, next LinkProber, call *linkCall) {
+	result := next.CheckLink(ctx, link)
+	if ctx.Err() == nil {
+		c.put(key, result)
+	}
+	call.cancel()
+	c.mu.Lock()
+	if c.calls[key] == call {
+		delete(c.calls, key)
+	}
+	c.mu.Unlock()
+	call.result = result
+	close(call.done)
+}
+
+// leave drops a waiter that gave up, cancelling the call when it was the last.
+func (c *LinkCache) leave(key string, call *linkCall) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	call.waiters--
+	if call.waiters > 0 {
+		return
+	}
+	call.cancel()
+	// Later callers start afresh rather than join a cancelled check.
+	if c.calls[key] == call {
+		delete(c.calls, key)
+	}
+}
+
+func (c *LinkCache) get(key string) (model.LinkCheck, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		return model.LinkCheck{}, false
+	}
+	entry := el.Value.(*linkCacheEntry)
+	if !c.now().Before(entry.expires) {
+		c.lru.Remove(el)
+		delete(c.entries, key)
+		return model.LinkCheck{}, false
+	}
+	c.lru.MoveToFront(el)
+	result := entry.result
+	result.Cached = true
+	return result, true
+}
+
+func (c *LinkCache) put(key string, result model.LinkCheck) {
+	ttl := c.cfg.NegativeTTL
+	if result.Accessible {
+		ttl = c.cfg.PositiveTTL
+	}
+	if ttl <= 0 || c.cfg.MaxEntries <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry := &linkCacheEntry{key: key, result: result, expires: c.now().Add(ttl)}
+	if el, ok := c.entries[key]; ok {
+		el.Value = entry
+		c.lru.MoveToFront(el)
+		return
+	}
+	c.entries[key] = c.lru.PushFront(entry)
+	for c.lru.Len() > c.cfg.MaxEntries {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*linkCacheEntry).key)
+	}
+}
+
+type cachedLinkChecker struct {
+	cache   *LinkCache
+	next    LinkProber
+	scope   string
+	metrics metrics.Sink
+}
+
+func (c *cachedLinkChecker) IsAccessibleContext(ctx context.Context, link string) bool {
+	return c.CheckLink(ctx, link).Accessible
+}
+
+func (c *cachedLinkChecker) CheckLink(ctx context.Context, link string) model.LinkCheck {
+	return c.cache.check(ctx, c.scope, link, c.next, c.metrics)
+}
@@ -0,0 +1,165 @@
+package factory
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+	"web-analyzer-go/internal/model"
+)
+
+// countingProber reports links containing "bad" as inaccessible and counts
+// calls. When gate is set, checks block until it is closed.
+type countingProber struct {
+	calls atomic.Int32
+	gate  chan struct{}
+}
+
+func (p *countingProber) IsAccessibleContext(ctx context.Context, link string) bool {
+	return p.CheckLink(ctx, link).Accessible
+}
+
+func (p *countingProber) CheckLink(_ context.Context, link string) model.LinkCheck {
+	p.calls.Add(1)
+	if p.gate != nil {
+		<-p.gate
+	}
+	return model.LinkCheck{Accessible: !strings.Contains(link, "bad"), StatusCode: 200}
+}
+
+func TestLinkCache_ttlAndEviction(t *testing.T) {
+	now := time.Unix(0, 0)
+	cache := NewLinkCache(LinkCacheConfig{PositiveTTL: time.Minute, NegativeTTL: time.Second, MaxEntries: 2})
+	cache.now = func() time.Time { return now }
+	prober := &countingProber{}
+	checker := cache.Wrap(prober).(LinkProber)
+	ctx := context.Background()
+
+	if res := checker.CheckLink(ctx, "http://a/"); res.Cached {
+		t.Fatalf("first check should not be cached: %+v", res)
+	}
+	if res := checker.CheckLink(ctx, "http://a/"); !res.Cached || !res.Accessible || prober.calls.Load() != 1 {
+		t.Fatalf("expected cached hit, got %+v after %d calls", res, prober.calls.Load())
+	}
+
+	checker.CheckLink(ctx, "http://bad/")
+	now = now.Add(2 * time.Second)
+	if res := checker.CheckLink(ctx, "http://bad/"); res.Cached || prober.calls.Load() != 3 {
+		t.Fatalf("negative entry should have expired: %+v", res)
+	}
+	if res := checker.CheckLink(ctx, "http://a/"); !res.Cached {
+		t.Fatalf("positive entry should still be cached: %+v", res)
+	}
+
+	// http://bad/ is now the least recently used and is evicted.
+	checker.CheckLink(ctx, "http://c/")
+	if cache.Len() != 2 {
+		t.Fatalf("expected 2 entries, got %d", cache.Len())
+	}
+	if res := checker.CheckLink(ctx, "http://a/"); !res.Cached {
+		t.Fatalf("recently used entry was evicted")
+	}
+	calls := prober.calls.Load()
+	if checker.CheckLink(ctx, "http://bad/"); prober.calls.Load() != calls+1 {
+		t.Fatalf("expected evicted entry to be re-checked")
+	}
+}
+
+func TestLinkCache_coalescesConcurrentChecks(t *testing.T) {
+	cache := NewLinkCache(DefaultLinkCacheConfig())
+	prober := &countingProber{gate: make(chan struct{})}
+	checker := cache.Wrap(prober)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if !checker.IsAccessibleContext(context.Background(), "http://a/") {
+				t.Errorf("expected accessible")
+			}
+		}()
+	}
+	// Let the callers pile up behind the first check before releasing it.
+	time.Sleep(20 * time.Millisecond)
+	close(prober.gate)
+	wg.Wait()
+	if n := prober.calls.Load(); n != 1 {
+		t.Fatalf("expected one upstream check, got %d", n)
+	}
+}
+
+// ctxProber blocks until release is closed or its context is done, and
+// reports whether the context was cancelled.
+type ctxProber struct {
+	release   chan struct{}
+	cancelled chan struct{}
+}
+
+func (p *ctxProber) IsAccessibleContext(ctx context.Context, link string) bool {
+	return p.CheckLink(ctx, link).Accessible
+}
+
+func (p *ctxProber) CheckLink(ctx context.Context, _ string) model.LinkCheck {
+	select {
+	case <-p.release:
+		return model.LinkCheck{Accessible: true, StatusCode: 200}
+	case <-ctx.Done():
+		close(p.cancelled)
+		return model.LinkCheck{Category: errorCategory(ctx.Err()), Error: ctx.Err().Error()}
+	}
+}
+
+func TestLinkCache_cancelsAbandonedChecks(t *testing.T) {
+	cache := NewLinkCache(DefaultLinkCacheConfig())
+	prober := &ctxProber{release: make(chan struct{}), cancelled: make(chan struct{})}
+	checker := cache.Wrap(prober).(LinkProber)
+
+	first, cancelFirst := context.WithCancel(context.Background())
+	second, cancelSecond := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	for _, ctx := range []context.Context{first, second} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			checker.CheckLink(ctx, "http://a/")
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	cancelFirst()
+	select {
+	case <-prober.cancelled:
+		t.Fatalf("check cancelled while another caller still waits")
+	case <-time.After(20 * time.Millisecond):
+	}
+	cancelSecond()
+	select {
+	case <-prober.cancelled:
+	case <-time.After(time.Second):
+		t.Fatalf("check kept running after every caller gave up")
+	}
+	wg.Wait()
+	// Give the abandoned check time to finish before looking at the cache.
+	time.Sleep(10 * time.Millisecond)
+	if cache.Len() != 0 {
+		t.Fatalf("abandoned check result was cached")
+	}
+}
+
+func TestLinkCache_keysOnUserAgent(t *testing.T) {
+	cache := NewLinkCache(DefaultLinkCacheConfig())
+	prober := &countingProber{}
+	a := cache.WrapNamespace(prober, "", "agent-a", nil).(LinkProber)
+	b := cache.WrapNamespace(prober, "", "agent-b", nil).(LinkProber)
+
+	a.CheckLink(context.Background(), "http://a/")
+	if res := b.CheckLink(context.Background(), "http://a/"); res.Cached || prober.calls.Load() != 2 {
+		t.Fatalf("expected another User-Agent to check again, got %+v after %d calls", res, prober.calls.Load())
+	}
+	if res := a.CheckLink(context.Background(), "http://a/"); !res.Cached {
+		t.Fatalf("expected the same User-Agent to hit the cache: %+v", res)
+	}
+}
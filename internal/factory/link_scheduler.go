@@ -0,0 +1,199 @@
+package factory
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+	"web-analyzer-go/internal/metrics"
+	"web-analyzer-go/internal/model"
+)
+
+// LinkSchedulerConfig limits outbound link checks across all analyses.
+// MaxInFlight caps concurrent checks overall and PerHostLimit caps them per
+// host; a non-positive value means no limit. PerHostDelay, when positive, is
+// the minimum time between the starts of two checks against the same host.
+type LinkSchedulerConfig struct {
+	MaxInFlight  int
+	PerHostLimit int
+	PerHostDelay time.Duration
+}
+
+// DefaultLinkSchedulerConfig returns the limits used when none are configured.
+func DefaultLinkSchedulerConfig() LinkSchedulerConfig {
+	return LinkSchedulerConfig{
+		MaxInFlight:  64,
+		PerHostLimit: 4,
+	}
+}
+
+// LinkScheduler admits link checks so that concurrent analyses together
+// respect the configured global and per-host limits. Checks waiting for a
+// slot are reported by the analyzer_link_check_queue_depth gauge, or the sink
+// given to Wrap. A LinkScheduler is safe for concurrent use.
+type LinkScheduler struct {
+	cfg    LinkSchedulerConfig
+	global chan struct{}
+
+	mu    sync.Mutex
+	hosts map[string]*hostSlot
+}
+
+// hostSlot tracks one host's concurrency and pacing. Idle slots are dropped
+// once their pacing delay has passed.
+type hostSlot struct {
+	sem       chan struct{}
+	refs      int
+	nextStart time.Time
+}
+
+// maxIdleHosts is how many idle host slots may accumulate before they are pruned.
+const maxIdleHosts = 1024
+
+// NewLinkScheduler returns a scheduler enforcing cfg.
+func NewLinkScheduler(cfg LinkSchedulerConfig) *LinkScheduler {
+	s := &LinkScheduler{cfg: cfg, hosts: make(map[string]*hostSlot)}
+	if cfg.MaxInFlight > 0 {
+		s.global = make(chan struct{}, cfg.MaxInFlight)
+	}
+	return s
+}
+
+// Wrap returns a checker that waits for a slot before delegating to next.
+// Queued checks are reported to sink, or to Prometheus when nil.
+func (s *LinkScheduler) Wrap(next ContextLinkChecker, sink metrics.Sink) ContextLinkChecker {
+	if s == nil || next == nil {
+		return next
+	}
+	return &scheduledLinkChecker{scheduler: s, next: AdaptLinkProber(next), metrics: metrics.OrDefault(sink)}
+}
+
+// acquire blocks until a check against host may start. The returned release
+// must be called when the check is done.
+func (s *LinkScheduler) acquire(ctx context.Context, host string, sink metrics.Sink) (release func(), err error) {
+	sink.AddLinkCheckQueueDepth(1)
+	defer sink.AddLinkCheckQueueDepth(-1)
+
+	slot := s.hostSlot(host)
+	releaseHost := func() { s.releaseHost(host, slot) }
+	if slot.sem != nil {
+		select {
+		case slot.sem <- struct{}{}:
+			inner := releaseHost
+			releaseHost = func() { <-slot.sem; inner() }
+		case <-ctx.Done():
+			releaseHost()
+			return nil, ctx.Err()
+		}
+	}
+	if err := s.pace(ctx, slot); err != nil {
+		releaseHost()
+		return nil, err
+	}
+	if s.global != nil {
+		select {
+		case s.global <- struct{}{}:
+		case <-ctx.Done():
+			releaseHost()
+			return nil, ctx.Err()
+		}
+		return func() { <-s.global; releaseHost() }, nil
+	}
+	return releaseHost, nil
+}
+
+// pace waits until the host's next start time and reserves the one after it.
+func (s *LinkScheduler) pace(ctx context.Context, slot *hostSlot) error {
+	if s.cfg.PerHostDelay <= 0 {
+		return nil
+	}
+	s.mu.Lock()
+	now := time.Now()
+	start := slot.nextStart
+	if start.Before(now) {
+		start = now
+	}
+	slot.nextStart = start.Add(s.cfg.PerHostDelay)
+	s.mu.Unlock()
+
+	wait := time.Until(start)
+	if wait <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *LinkScheduler) hostSlot(host string) *hostSlot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	slot, ok := s.hosts[host]
+	if !ok {
+		if len(s.hosts) >= maxIdleHosts {
+			s.pruneLocked()
+		}
+		slot = &hostSlot{}
+		if s.cfg.PerHostLimit > 0 {
+			slot.sem = make(chan struct{}, s.cfg.PerHostLimit)
+		}
+		s.hosts[host] = slot
+	}
+	slot.refs++
+	return slot
+}
+
+func (s *LinkScheduler) releaseHost(host string, slot *hostSlot) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	slot.refs--
+	if slot.refs == 0 && !time.Now().Before(slot.nextStart) {
+		delete(s.hosts, host)
+	}
+}
+
+// pruneLocked drops idle host slots whose pacing delay has passed.
+func (s *LinkScheduler) pruneLocked() {
+	now := time.Now()
+	for host, slot := range s.hosts {
+		if slot.refs == 0 && !now.Before(slot.nextStart) {
+			delete(s.hosts, host)
+		}
+	}
+}
+
+type scheduledLinkChecker struct {
+	scheduler *LinkScheduler
+	next      LinkProber
+	metrics   metrics.Sink
+}
+
+func (c *scheduledLinkChecker) IsAccessibleContext(ctx context.Context, link string) bool {
+	return c.CheckLink(ctx, link).Accessible
+}
+
+// CheckLink waits for a slot and then runs the check. Time spent queued is
+// not included in the reported latency.
+func (c *scheduledLinkChecker) CheckLink(ctx context.Context, link string) model.LinkCheck {
+	release, err := c.scheduler.acquire(ctx, linkHost(link), c.metrics)
+	if err != nil {
+		return model.LinkCheck{Category: errorCategory(err), Error: err.Error()}
+	}
+	defer release()
+	return c.next.CheckLink(ctx, link)
+}
+
+// linkHost returns the lowercased host[:port] of link, or "" if it does not parse.
+func linkHost(link string) string {
+	u, err := url.Parse(link)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Host)
+}
@@ -0,0 +1,113 @@
+package factory
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+	"web-analyzer-go/internal/metrics"
+	"web-analyzer-go/internal/model"
+)
+
+// concurrencyProber records the peak number of concurrent checks overall
+// and per host, and the start time of every check.
+type concurrencyProber struct {
+	mu         sync.Mutex
+	inFlight   map[string]int
+	total      int
+	peakHost   int
+	peakGlobal int
+	starts     []time.Time
+}
+
+func (p *concurrencyProber) IsAccessibleContext(ctx context.Context, link string) bool {
+	return p.CheckLink(ctx, link).Accessible
+}
+
+func (p *concurrencyProber) CheckLink(_ context.Context, link string) model.LinkCheck {
+	host := linkHost(link)
+	p.mu.Lock()
+	p.inFlight[host]++
+	p.total++
+	p.peakHost = max(p.peakHost, p.inFlight[host])
+	p.peakGlobal = max(p.peakGlobal, p.total)
+	p.starts = append(p.starts, time.Now())
+	p.mu.Unlock()
+
+	time.Sleep(10 * time.Millisecond)
+
+	p.mu.Lock()
+	p.inFlight[host]--
+	p.total--
+	p.mu.Unlock()
+	return model.LinkCheck{Accessible: true}
+}
+
+func runChecks(checker ContextLinkChecker, links []string) {
+	var wg sync.WaitGroup
+	for _, l := range links {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			checker.IsAccessibleContext(context.Background(), l)
+		}()
+	}
+	wg.Wait()
+}
+
+func TestLinkScheduler_limits(t *testing.T) {
+	prober := &concurrencyProber{inFlight: map[string]int{}}
+	checker := NewLinkScheduler(LinkSchedulerConfig{MaxInFlight: 3, PerHostLimit: 2}).Wrap(prober, nil)
+
+	var links []string
+	for i := 0; i < 12; i++ {
+		links = append(links, fmt.Sprintf("http://host%d.test/page%d", i%3, i))
+	}
+	runChecks(checker, links)
+
+	if prober.peakHost > 2 {
+		t.Errorf("per-host limit exceeded: %d", prober.peakHost)
+	}
+	if prober.peakGlobal > 3 {
+		t.Errorf("global limit exceeded: %d", prober.peakGlobal)
+	}
+}
+
+func TestLinkScheduler_hostDelay(t *testing.T) {
+	prober := &concurrencyProber{inFlight: map[string]int{}}
+	delay := 20 * time.Millisecond
+	checker := NewLinkScheduler(LinkSchedulerConfig{PerHostDelay: delay}).Wrap(prober, nil)
+
+	runChecks(checker, []string{"http://a.test/1", "http://a.test/2", "http://a.test/3"})
+
+	first, last := prober.starts[0], prober.starts[0]
+	for _, s := range prober.starts {
+		if s.Before(first) {
+			first = s
+		}
+		if s.After(last) {
+			last = s
+		}
+	}
+	// Three starts need at least two gaps; allow some timer slack.
+	if gap := last.Sub(first); gap < 2*delay-5*time.Millisecond {
+		t.Errorf("checks were not paced: spread %s", gap)
+	}
+}
+
+func TestLinkScheduler_cancelWhileQueued(t *testing.T) {
+	s := NewLinkScheduler(LinkSchedulerConfig{MaxInFlight: 1})
+	release, err := s.acquire(context.Background(), "a.test", metrics.PrometheusSink{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	res := s.Wrap(&concurrencyProber{inFlight: map[string]int{}}, nil).(LinkProber).CheckLink(ctx, "http://b.test/")
+	if res.Accessible || res.Error == "" {
+		t.Fatalf("expected queued check to give up, got %+v", res)
+	}
+}
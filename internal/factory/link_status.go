@@ -0,0 +1,81 @@
+package factory
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"web-analyzer-go/internal/model"
+)
+
+// statusCategory classifies an HTTP response status. redirected reports
+// whether the request ended somewhere other than the link itself.
+func statusCategory(code int, redirected bool) string {
+	switch {
+	case code == http.StatusUnauthorized || code == http.StatusForbidden || code == http.StatusProxyAuthRequired:
+		return model.LinkAuthRequired
+	case code == http.StatusTooManyRequests:
+		return model.LinkRateLimited
+	case code >= 500:
+		return model.LinkServerError
+	case code >= 400:
+		return model.LinkClientError
+	case code >= 300 || redirected:
+		return model.LinkRedirect
+	default:
+		return model.LinkOK
+	}
+}
+
+// errorCategory classifies a transport error.
+func errorCategory(err error) string {
+	var dnsErr *net.DNSError
+	var netErr net.Error
+	var certErr *tls.CertificateVerificationError
+	var alertErr tls.AlertError
+	var recordErr tls.RecordHeaderError
+	var unknownAuth x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var invalidCert x509.CertificateInvalidError
+	switch {
+	case errors.As(err, &dnsErr):
+		if dnsErr.IsTimeout {
+			return model.LinkTimeout
+		}
+		return model.LinkDNSError
+	case errors.As(err, &certErr), errors.As(err, &alertErr), errors.As(err, &recordErr),
+		errors.As(err, &unknownAuth), errors.As(err, &hostnameErr), errors.As(err, &invalidCert):
+		return model.LinkTLSError
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return model.LinkTimeout
+	default:
+		return model.LinkConnectionError
+	}
+}
+
+// botProtectionHeaders are set by common bot-mitigation services on the
+// challenge or block pages they serve instead of the requested content.
+var botProtectionHeaders = []string{"Cf-Mitigated", "X-Datadome", "X-Amzn-Waf-Action", "X-Sucuri-Block"}
+
+// isBotProtection reports whether a failed response looks like a bot
+// challenge rather than an answer about the link itself: LinkedIn's 999, or
+// a 403, 429 or 503 carrying the marks of a known mitigation service.
+func isBotProtection(code int, header http.Header) bool {
+	if code == 999 {
+		return true
+	}
+	if code != http.StatusForbidden && code != http.StatusTooManyRequests && code != http.StatusServiceUnavailable {
+		return false
+	}
+	for _, h := range botProtectionHeaders {
+		if header.Get(h) != "" {
+			return true
+		}
+	}
+	server := strings.ToLower(header.Get("Server"))
+	return (code == http.StatusForbidden || code == http.StatusServiceUnavailable) &&
+		(strings.Contains(server, "cloudflare") || strings.Contains(server, "akamaighost") || strings.Contains(server, "ddos-guard"))
+}
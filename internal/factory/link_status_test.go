@@ -0,0 +1,102 @@
+package factory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"web-analyzer-go/internal/model"
+)
+
+func TestStatusCategory(t *testing.T) {
+	cases := []struct {
+		code       int
+		redirected bool
+		want       string
+	}{
+		{200, false, model.LinkOK},
+		{206, false, model.LinkOK},
+		{200, true, model.LinkRedirect},
+		{304, false, model.LinkRedirect},
+		{401, false, model.LinkAuthRequired},
+		{403, false, model.LinkAuthRequired},
+		{404, false, model.LinkClientError},
+		{429, false, model.LinkRateLimited},
+		{503, false, model.LinkServerError},
+	}
+	for _, tc := range cases {
+		if got := statusCategory(tc.code, tc.redirected); got != tc.want {
+			t.Errorf("statusCategory(%d, %v) = %s, want %s", tc.code, tc.redirected, got, tc.want)
+		}
+	}
+}
+
+func TestErrorCategory(t *testing.T) {
+	cases := []struct {
+		err  error
+		want string
+	}{
+		{&net.DNSError{Err: "no such host", Name: "nope.invalid", IsNotFound: true}, model.LinkDNSError},
+		{fmt.Errorf("get: %w", context.DeadlineExceeded), model.LinkTimeout},
+		{&net.OpError{Op: "dial", Err: errors.New("connection refused")}, model.LinkConnectionError},
+	}
+	for _, tc := range cases {
+		if got := errorCategory(tc.err); got != tc.want {
+			t.Errorf("errorCategory(%v) = %s, want %s", tc.err, got, tc.want)
+		}
+	}
+}
+
+func TestDefaultLinkChecker_categories(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/challenge", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cf-Mitigated", "challenge")
+		w.WriteHeader(http.StatusForbidden)
+	})
+	mux.HandleFunc("/private", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusUnauthorized) })
+	mux.HandleFunc("/missing", http.NotFound)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	tlsSrv := httptest.NewTLSServer(mux)
+	defer tlsSrv.Close()
+
+	c := &DefaultLinkChecker{Client: srv.Client(), Retry: RetryPolicy{MaxAttempts: 1}}
+	cases := []struct {
+		link     string
+		category string
+		blocked  bool
+	}{
+		{srv.URL + "/challenge", model.LinkAuthRequired, true},
+		{srv.URL + "/private", model.LinkAuthRequired, false},
+		{srv.URL + "/missing", model.LinkClientError, false},
+		// The plain client does not trust the test server's certificate.
+		{tlsSrv.URL + "/missing", model.LinkTLSError, false},
+	}
+	for _, tc := range cases {
+		res := c.CheckLink(context.Background(), tc.link)
+		if res.Accessible || res.Category != tc.category || res.Blocked != tc.blocked || res.Error == "" {
+			t.Errorf("%s: got %+v, want category %s blocked %v", tc.link, res, tc.category, tc.blocked)
+		}
+	}
+}
+
+func TestDefaultLinkChecker_headOnly(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+	defer srv.Close()
+
+	full := &DefaultLinkChecker{Client: srv.Client()}
+	if res := full.CheckLink(context.Background(), srv.URL); !res.Accessible {
+		t.Fatalf("expected the GET fallback to succeed, got %+v", res)
+	}
+	head := &DefaultLinkChecker{Client: srv.Client(), HeadOnly: true}
+	if res := head.CheckLink(context.Background(), srv.URL); res.Accessible || res.StatusCode != http.StatusMethodNotAllowed {
+		t.Fatalf("expected HEAD-only to report the 405, got %+v", res)
+	}
+}
@@ -0,0 +1,114 @@
+package factory
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy bounds how DefaultLinkChecker retries transient failures: 429,
+// 502, 503 and 504 responses and connections reset or closed mid-exchange.
+// Retries back off exponentially from BaseDelay up to MaxDelay with jitter. A
+// Retry-After header replaces the backoff when it asks for no more than
+// MaxRetryAfter; a longer one ends the retries. MaxAttempts counts the first
+// try, so 1 disables retries.
+type RetryPolicy struct {
+	MaxAttempts   int
+	BaseDelay     time.Duration
+	MaxDelay      time.Duration
+	MaxRetryAfter time.Duration
+}
+
+// DefaultRetryPolicy returns the policy used when none is configured.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:   3,
+		BaseDelay:     200 * time.Millisecond,
+		MaxDelay:      2 * time.Second,
+		MaxRetryAfter: 5 * time.Second,
+	}
+}
+
+// orDefault returns p, or DefaultRetryPolicy for the zero value.
+func (p RetryPolicy) orDefault() RetryPolicy {
+	if p == (RetryPolicy{}) {
+		return DefaultRetryPolicy()
+	}
+	return p
+}
+
+// backoff returns the jittered delay before retry number n (starting at 1):
+// half the exponential step plus a random share of the other half.
+func (p RetryPolicy) backoff(n int) time.Duration {
+	d := p.BaseDelay << (n - 1)
+	if d <= 0 || d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	if d <= 0 {
+		return 0
+	}
+	half := d / 2
+	return half + rand.N(d-half+1)
+}
+
+// isTransientStatus reports whether a response status is worth retrying.
+func isTransientStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// isTransientError reports whether a transport error is worth retrying.
+// Timeouts are not: the attempt already used its whole time allowance.
+func isTransientError(err error) bool {
+	return errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNABORTED) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds or as an HTTP
+// date. ok is false when the header is absent or malformed.
+func parseRetryAfter(v string, now time.Time) (d time.Duration, ok bool) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+	t, err := http.ParseTime(v)
+	if err != nil {
+		return 0, false
+	}
+	if d = t.Sub(now); d < 0 {
+		d = 0
+	}
+	return d, true
+}
+
+// sleepContext waits for d or until ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
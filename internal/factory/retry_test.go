@@ -0,0 +1,90 @@
+package factory
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fastRetry keeps test backoffs short.
+var fastRetry = RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond, MaxRetryAfter: time.Second}
+
+func TestDefaultLinkChecker_retriesTransientFailures(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch hits.Add(1) {
+		case 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case 2, 3:
+			// Drop the connection without a response, for both the HEAD
+			// and the GET fallback of the second attempt.
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			conn.Close()
+		}
+	}))
+	defer srv.Close()
+
+	// Without keep-alives the transport cannot silently replay the request
+	// on a fresh connection, so the checker sees the dropped connection.
+	client := srv.Client()
+	client.Transport.(*http.Transport).DisableKeepAlives = true
+	c := &DefaultLinkChecker{Client: client, Retry: fastRetry}
+	res := c.CheckLink(context.Background(), srv.URL)
+	if !res.Accessible || res.Attempts != 3 {
+		t.Fatalf("expected success on the third attempt, got %+v", res)
+	}
+}
+
+func TestDefaultLinkChecker_doesNotRetryPermanentFailures(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		http.NotFound(w, r)
+	}))
+	defer srv.Close()
+
+	res := (&DefaultLinkChecker{Client: srv.Client(), Retry: fastRetry}).CheckLink(context.Background(), srv.URL)
+	if res.Accessible || res.Attempts != 1 || hits.Load() != 1 {
+		t.Fatalf("expected a single attempt, got %+v after %d requests", res, hits.Load())
+	}
+}
+
+func TestDefaultLinkChecker_retryAfterCap(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Header().Set("Retry-After", "120")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	res := (&DefaultLinkChecker{Client: srv.Client(), Retry: fastRetry}).CheckLink(context.Background(), srv.URL)
+	if res.Accessible || res.Attempts != 1 || res.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("expected to give up when Retry-After exceeds the cap, got %+v", res)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		in   string
+		want time.Duration
+		ok   bool
+	}{
+		{"3", 3 * time.Second, true},
+		{now.Add(10 * time.Second).Format(http.TimeFormat), 10 * time.Second, true},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0, true},
+		{"", 0, false},
+		{"-1", 0, false},
+		{"soon", 0, false},
+	}
+	for _, tc := range cases {
+		got, ok := parseRetryAfter(tc.in, now)
+		if got != tc.want || ok != tc.ok {
+			t.Errorf("parseRetryAfter(%q) = %s, %v; want %s, %v", tc.in, got, ok, tc.want, tc.ok)
+		}
+	}
+}
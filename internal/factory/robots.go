@@ -0,0 +1,300 @@
+package factory
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"web-analyzer-go/internal/model"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// RobotsAgent is the product token matched against robots.txt user-agent
+// lines, whatever User-Agent header a request sends.
+const RobotsAgent = "web-analyzer-go"
+
+// RobotsConfig bounds the robots.txt cache. Rules are reused for TTL, files
+// are read up to MaxBytes, and at most MaxEntries origins are remembered.
+type RobotsConfig struct {
+	TTL        time.Duration
+	MaxEntries int
+	MaxBytes   int64
+}
+
+// DefaultRobotsConfig returns the limits used when none are configured.
+func DefaultRobotsConfig() RobotsConfig {
+	return RobotsConfig{
+		TTL:        time.Hour,
+		MaxEntries: 1000,
+		MaxBytes:   500 << 10,
+	}
+}
+
+// robotsFailureTTL is how long an unreachable robots.txt is remembered
+// before it is tried again.
+const robotsFailureTTL = time.Minute
+
+// RobotsCache fetches, parses and caches robots.txt per origin. Concurrent
+// lookups for the same origin share one fetch. A robots.txt that is missing
+// or answers 4xx allows everything, and so, unlike what RFC 9309 asks of
+// crawlers, does one that cannot be fetched or answers 5xx: the analyzer only
+// ever requests what a page links to. A RobotsCache is safe for concurrent use.
+type RobotsCache struct {
+	client *http.Client
+	cfg    RobotsConfig
+	now    func() time.Time
+	group  singleflight.Group
+
+	mu      sync.Mutex
+	entries map[string]robotsEntry
+}
+
+type robotsEntry struct {
+	rules   *robotsRules
+	expires time.Time
+}
+
+// NewRobotsCache returns an empty cache fetching with client, or the shared
+// guarded client when nil.
+func NewRobotsCache(client *http.Client, cfg RobotsConfig) *RobotsCache {
+	if client == nil {
+		client = getSharedHTTPClient()
+	}
+	return &RobotsCache{client: client, cfg: cfg, now: time.Now, entries: make(map[string]robotsEntry)}
+}
+
+// Allowed reports whether the origin's robots.txt lets RobotsAgent fetch link.
+func (c *RobotsCache) Allowed(ctx context.Context, link string) bool {
+	u, err := url.Parse(link)
+	if err != nil || u.Host == "" {
+		return true
+	}
+	if u.EscapedPath() == "/robots.txt" {
+		return true
+	}
+	return c.rules(ctx, u.Scheme+"://"+strings.ToLower(u.Host)).allowed(requestPath(u))
+}
+
+// Wrap returns a checker that looks up robots.txt before delegating to next.
+// Disallowed links are flagged RobotsDisallowed; with obey they are not
+// requested at all and get the disallowed_by_robots category instead.
+func (c *RobotsCache) Wrap(next ContextLinkChecker, obey bool) ContextLinkChecker {
+	if c == nil || next == nil {
+		return next
+	}
+	return &robotsLinkChecker{cache: c, next: AdaptLinkProber(next), obey: obey}
+}
+
+func (c *RobotsCache) rules(ctx context.Context, origin string) *robotsRules {
+	c.mu.Lock()
+	entry, ok := c.entries[origin]
+	c.mu.Unlock()
+	if ok && c.now().Before(entry.expires) {
+		return entry.rules
+	}
+
+	ch := c.group.DoChan(origin, func() (any, error) {
+		rules, ttl := c.fetch(context.WithoutCancel(ctx), origin)
+		c.put(origin, robotsEntry{rules: rules, expires: c.now().Add(ttl)})
+		return rules, nil
+	})
+	select {
+	case res := <-ch:
+		return res.Val.(*robotsRules)
+	case <-ctx.Done():
+		return nil
+	}
+}
+
+func (c *RobotsCache) put(origin string, entry robotsEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cfg.MaxEntries <= 0 {
+		return
+	}
+	if _, ok := c.entries[origin]; !ok && len(c.entries) >= c.cfg.MaxEntries {
+		now := c.now()
+		for o, e := range c.entries {
+			if !now.Before(e.expires) {
+				delete(c.entries, o)
+			}
+		}
+		// Still full: drop an arbitrary entry rather than grow.
+		for o := range c.entries {
+			if len(c.entries) < c.cfg.MaxEntries {
+				break
+			}
+			delete(c.entries, o)
+		}
+	}
+	c.entries[origin] = entry
+}
+
+// fetch retrieves origin's robots.txt and returns its rules for RobotsAgent,
+// nil meaning everything is allowed, and how long to keep them.
+func (c *RobotsCache) fetch(ctx context.Context, origin string) (*robotsRules, time.Duration) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, origin+"/robots.txt", nil)
+	if err != nil {
+		return nil, robotsFailureTTL
+	}
+	req.Header.Set("User-Agent", UserAgent)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, robotsFailureTTL
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode >= 500:
+		return nil, robotsFailureTTL
+	case resp.StatusCode >= 400:
+		return nil, c.cfg.TTL
+	case resp.StatusCode >= 300:
+		// Redirects beyond the client's limit.
+		return nil, robotsFailureTTL
+	}
+	limit := c.cfg.MaxBytes
+	if limit <= 0 {
+		limit = DefaultRobotsConfig().MaxBytes
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, limit))
+	if err != nil {
+		return nil, robotsFailureTTL
+	}
+	return parseRobots(body, RobotsAgent), c.cfg.TTL
+}
+
+// requestPath is the part of u robots.txt rules are matched against.
+func requestPath(u *url.URL) string {
+	p := u.EscapedPath()
+	if p == "" {
+		p = "/"
+	}
+	if u.RawQuery != "" {
+		p += "?" + u.RawQuery
+	}
+	return p
+}
+
+// robotsRules are the allow and disallow rules of the group that applies to
+// one user agent.
+type robotsRules struct {
+	rules []robotsRule
+}
+
+type robotsRule struct {
+	allow   bool
+	pattern string
+	re      *regexp.Regexp
+}
+
+// allowed applies the most specific matching rule, preferring allow on ties.
+// A nil receiver allows everything.
+func (r *robotsRules) allowed(path string) bool {
+	if r == nil {
+		return true
+	}
+	best, allow := -1, true
+	for _, rule := range r.rules {
+		if !rule.re.MatchString(path) {
+			continue
+		}
+		if n := len(rule.pattern); n > best || (n == best && rule.allow) {
+			best, allow = n, rule.allow
+		}
+	}
+	return allow
+}
+
+// parseRobots returns the rules of the groups naming agent, or of the "*"
+// groups when none does. Agent names match case-insensitively, ignoring any
+// version suffix.
+func parseRobots(body []byte, agent string) *robotsRules {
+	var specific, wildcard []robotsRule
+	matchedSpecific := false
+	var groupAgents []string
+	inRules := false
+
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key, value = strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(value)
+		switch key {
+		case "user-agent":
+			if inRules {
+				groupAgents, inRules = nil, false
+			}
+			name, _, _ := strings.Cut(strings.ToLower(value), "/")
+			groupAgents = append(groupAgents, strings.TrimSpace(name))
+		case "allow", "disallow":
+			inRules = true
+			if value == "" {
+				continue // an empty disallow allows everything
+			}
+			rule := robotsRule{allow: key == "allow", pattern: value, re: robotsPattern(value)}
+			for _, name := range groupAgents {
+				switch name {
+				case strings.ToLower(agent):
+					matchedSpecific = true
+					specific = append(specific, rule)
+				case "*":
+					wildcard = append(wildcard, rule)
+				}
+			}
+		}
+	}
+	if matchedSpecific {
+		return &robotsRules{rules: specific}
+	}
+	return &robotsRules{rules: wildcard}
+}
+
+// robotsPattern compiles a path pattern where * matches any characters and a
+// trailing $ anchors the end.
+func robotsPattern(pattern string) *regexp.Regexp {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+	parts := strings.Split(pattern, "*")
+	for i, p := range parts {
+		parts[i] = regexp.QuoteMeta(p)
+	}
+	expr := "^" + strings.Join(parts, ".*")
+	if anchored {
+		expr += "$"
+	}
+	return regexp.MustCompile(expr)
+}
+
+type robotsLinkChecker struct {
+	cache *RobotsCache
+	next  LinkProber
+	obey  bool
+}
+
+func (c *robotsLinkChecker) IsAccessibleContext(ctx context.Context, link string) bool {
+	return c.CheckLink(ctx, link).Accessible
+}
+
+func (c *robotsLinkChecker) CheckLink(ctx context.Context, link string) model.LinkCheck {
+	if c.cache.Allowed(ctx, link) {
+		return c.next.CheckLink(ctx, link)
+	}
+	if c.obey {
+		return model.LinkCheck{Category: model.LinkDisallowedByRobots, RobotsDisallowed: true, Error: "disallowed by robots.txt"}
+	}
+	result := c.next.CheckLink(ctx, link)
+	result.RobotsDisallowed = true
+	return result
+}
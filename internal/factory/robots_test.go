@@ -0,0 +1,72 @@
+package factory
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"web-analyzer-go/internal/model"
+)
+
+func TestParseRobots(t *testing.T) {
+	body := []byte(`# comments are ignored
+User-agent: *
+Disallow: /
+
+User-agent: Googlebot
+User-agent: Web-Analyzer-Go/2.0
+Disallow: /private
+Allow: /private/press
+Disallow: /*.pdf$
+Disallow:
+`)
+	rules := parseRobots(body, RobotsAgent)
+	cases := map[string]bool{
+		"/":                      true,
+		"/private":               false,
+		"/private/notes":         false,
+		"/private/press/release": true,
+		"/docs/guide.pdf":        false,
+		"/docs/guide.pdf?dl=1":   true,
+	}
+	for path, want := range cases {
+		if got := rules.allowed(path); got != want {
+			t.Errorf("%s: allowed=%v, want %v", path, got, want)
+		}
+	}
+	// Without a group of its own the agent falls back to "*".
+	if parseRobots(body, "other-bot").allowed("/anything") {
+		t.Errorf("expected the * group to disallow everything for other agents")
+	}
+}
+
+func TestRobotsCache_wrap(t *testing.T) {
+	var robotsHits atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/robots.txt", func(w http.ResponseWriter, r *http.Request) {
+		robotsHits.Add(1)
+		w.Write([]byte("User-agent: *\nDisallow: /admin\n"))
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	cache := NewRobotsCache(srv.Client(), DefaultRobotsConfig())
+	next := &DefaultLinkChecker{Client: srv.Client()}
+	obey := AdaptLinkProber(cache.Wrap(next, true))
+	report := AdaptLinkProber(cache.Wrap(next, false))
+
+	if res := obey.CheckLink(context.Background(), srv.URL+"/admin/users"); res.Category != model.LinkDisallowedByRobots || !res.RobotsDisallowed || res.StatusCode != 0 {
+		t.Fatalf("expected obey to skip the disallowed link, got %+v", res)
+	}
+	if res := report.CheckLink(context.Background(), srv.URL+"/admin/users"); !res.Accessible || !res.RobotsDisallowed || res.StatusCode != http.StatusOK {
+		t.Fatalf("expected report-only to check and flag the link, got %+v", res)
+	}
+	if res := obey.CheckLink(context.Background(), srv.URL+"/about"); !res.Accessible || res.RobotsDisallowed {
+		t.Fatalf("expected an allowed link to be checked normally, got %+v", res)
+	}
+	if n := robotsHits.Load(); n != 1 {
+		t.Fatalf("expected robots.txt to be fetched once, got %d", n)
+	}
+}
@@ -0,0 +1,266 @@
+package factory
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode"
+	"web-analyzer-go/internal/util"
+
+	"golang.org/x/net/html"
+)
+
+// soft404BodyBytes is how much of a page is read when MaxBodyBytes is unset.
+const soft404BodyBytes = 64 << 10
+
+// Soft404Threshold is the confidence from which a page is reported as a
+// likely soft 404.
+const Soft404Threshold = 0.5
+
+// notFoundPattern matches titles and headings of typical error pages.
+var notFoundPattern = regexp.MustCompile(`(?i)\b404\b|not\s+found|page\s+(?:does\s*n[o']?t|doesn't|no\s+longer)\s+exists?|(?:cannot|can't|could\s*n[o']?t)\s+(?:be\s+)?f(?:ou|i)nd|no\s+longer\s+available|nothing\s+(?:was\s+)?found|page\s+(?:is\s+)?missing`)
+
+// Soft404Detector looks for pages that answer 200 but are really "not
+// found" pages. It reads at most MaxBodyBytes of each page, detects and
+// decodes HTML as the analyzed page is, and compares it with the site's
+// answer to a random path that cannot exist, fetched once per origin and
+// directory since sites often route sections to different apps.
+// Every request, the baseline probes included, goes through Gate. A detector
+// is meant to serve one analysis and is safe for concurrent use.
+type Soft404Detector struct {
+	Client *http.Client
+	// UserAgent is sent as DefaultLinkChecker.UserAgent is.
+	UserAgent string
+	// MaxBodyBytes caps how much of each page is read; 0 means 64 KiB.
+	MaxBodyBytes int64
+	// Gate paces the requests and applies the robots.txt policy; nil
+	// admits them all.
+	Gate *FetchGate
+
+	mu        sync.Mutex
+	baselines map[string]*soft404Baseline
+}
+
+// soft404Baseline is a site's answer to a path that does not exist.
+type soft404Baseline struct {
+	once sync.Once
+	page *soft404Page
+}
+
+// soft404Page is what the detector learned from one response.
+type soft404Page struct {
+	status   int
+	finalURL string
+	title    string
+	headings []string
+	tokens   map[string]struct{}
+}
+
+// Soft404Result is the verdict for one link.
+type Soft404Result struct {
+	Likely     bool
+	Confidence float64
+}
+
+// Check fetches link and estimates how likely it is a soft 404. Links that
+// cannot be fetched or are not HTML get a zero result.
+func (d *Soft404Detector) Check(ctx context.Context, link string) Soft404Result {
+	page, err := d.fetch(ctx, link)
+	if err != nil || page == nil {
+		return Soft404Result{}
+	}
+	confidence := soft404Confidence(link, page, d.baseline(ctx, link))
+	return Soft404Result{Likely: confidence >= Soft404Threshold, Confidence: confidence}
+}
+
+// soft404Confidence combines independent signals as 1 - Π(1 - s).
+func soft404Confidence(link string, page, baseline *soft404Page) float64 {
+	var signals []float64
+	if notFoundPattern.MatchString(page.title) {
+		signals = append(signals, 0.6)
+	}
+	for _, h := range page.headings {
+		if notFoundPattern.MatchString(h) {
+			signals = append(signals, 0.4)
+			break
+		}
+	}
+
+	siteSoft404s := baseline != nil && baseline.status >= 200 && baseline.status < 300
+	if siteSoft404s {
+		if page.finalURL != link && page.finalURL == baseline.finalURL {
+			signals = append(signals, 0.9)
+		}
+		if page.title != "" && page.title == baseline.title {
+			signals = append(signals, 0.7)
+		}
+		switch sim := jaccard(page.tokens, baseline.tokens); {
+		case sim >= 0.9:
+			signals = append(signals, 0.8)
+		case sim >= 0.75:
+			signals = append(signals, 0.5)
+		}
+	}
+
+	miss := 1.0
+	for _, s := range signals {
+		miss *= 1 - s
+	}
+	confidence := 1 - miss
+	if baseline != nil && (baseline.status == http.StatusNotFound || baseline.status == http.StatusGone) {
+		// The site answers missing pages properly, so error-like wording on
+		// a 200 page is weaker evidence.
+		confidence *= 0.6
+	}
+	return confidence
+}
+
+// baseline returns the site's answer to a random sibling of link, fetched
+// once per origin and directory, or nil if it could not be retrieved.
+func (d *Soft404Detector) baseline(ctx context.Context, link string) *soft404Page {
+	u, err := url.Parse(link)
+	if err != nil {
+		return nil
+	}
+	dir := path.Dir(u.EscapedPath())
+	if !strings.HasSuffix(dir, "/") {
+		dir += "/"
+	}
+	prefix := u.Scheme + "://" + u.Host + dir
+	d.mu.Lock()
+	if d.baselines == nil {
+		d.baselines = make(map[string]*soft404Baseline)
+	}
+	b, ok := d.baselines[prefix]
+	if !ok {
+		b = &soft404Baseline{}
+		d.baselines[prefix] = b
+	}
+	d.mu.Unlock()
+
+	b.once.Do(func() {
+		b.page, _ = d.fetch(ctx, prefix+randomPathSegment()+"-soft404-probe")
+	})
+	return b.page
+}
+
+func randomPathSegment() string {
+	buf := make([]byte, 12)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// fetch GETs link through the gate and extracts what the comparison needs. A
+// nil page with a nil error means the response was not HTML.
+func (d *Soft404Detector) fetch(ctx context.Context, link string) (page *soft404Page, err error) {
+	err = d.Gate.Do(ctx, link, func() error {
+		page, err = d.get(ctx, link)
+		return err
+	})
+	return page, err
+}
+
+func (d *Soft404Detector) get(ctx context.Context, link string) (*soft404Page, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", userAgentOrDefault(d.UserAgent))
+	resp, err := d.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	limit := d.MaxBodyBytes
+	if limit <= 0 {
+		limit = soft404BodyBytes
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, limit))
+	if err != nil {
+		return nil, err
+	}
+
+	page := &soft404Page{status: resp.StatusCode, finalURL: resp.Request.URL.String()}
+	contentType := resp.Header.Get("Content-Type")
+	if _, _, isHTML := util.DetectMediaType(contentType, body); !isHTML {
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			return nil, nil
+		}
+		// Error pages are kept for their status even when they are not HTML.
+		return page, nil
+	}
+	decoded, _ := util.DecodeHTML(body, contentType)
+	doc, err := html.Parse(bytes.NewReader(decoded))
+	if err != nil {
+		return nil, err
+	}
+	page.title, page.headings, page.tokens = pageText(doc)
+	return page, nil
+}
+
+// pageText returns a page's title, its h1 and h2 texts and the set of words
+// in its visible text.
+func pageText(doc *html.Node) (title string, headings []string, tokens map[string]struct{}) {
+	tokens = make(map[string]struct{})
+	var text func(*html.Node) string
+	text = func(n *html.Node) string {
+		if n.Type == html.TextNode {
+			return n.Data
+		}
+		var b strings.Builder
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			b.WriteString(text(c))
+			b.WriteByte(' ')
+		}
+		return b.String()
+	}
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.Data {
+			case "script", "style", "noscript", "template":
+				return
+			case "title":
+				if title == "" {
+					title = strings.Join(strings.Fields(text(n)), " ")
+				}
+				return
+			case "h1", "h2":
+				headings = append(headings, strings.Join(strings.Fields(text(n)), " "))
+			}
+		}
+		if n.Type == html.TextNode {
+			for _, w := range strings.FieldsFunc(strings.ToLower(n.Data), func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) }) {
+				tokens[w] = struct{}{}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+	return title, headings, tokens
+}
+
+// jaccard returns the Jaccard similarity of two word sets.
+func jaccard(a, b map[string]struct{}) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 0
+	}
+	shared := 0
+	for w := range a {
+		if _, ok := b[w]; ok {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
@@ -0,0 +1,113 @@
+package factory
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"unicode/utf16"
+)
+
+func htmlPage(w http.ResponseWriter, title, body string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprintf(w, "<html><head><title>%s</title></head><body>%s</body></html>", title, body)
+}
+
+func TestSoft404Detector(t *testing.T) {
+	article := "<h1>Shipping rates</h1><p>We ship worldwide within five business days using tracked couriers.</p>"
+	mux := http.NewServeMux()
+	// A site that answers every unknown path with a 200 error page.
+	mux.HandleFunc("/worded/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/worded/shipping" {
+			htmlPage(w, "Shipping", article)
+			return
+		}
+		htmlPage(w, "Page not found", "<h1>Oops</h1><p>Try the search box.</p>")
+	})
+	// A site whose catch-all page has no error wording at all.
+	mux.HandleFunc("/plain/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/plain/shipping" {
+			htmlPage(w, "Acme - Shipping", article)
+			return
+		}
+		htmlPage(w, "Acme", "<p>Welcome to Acme, browse our catalogue.</p>")
+	})
+	// A site with proper 404s and an article that merely mentions one.
+	mux.HandleFunc("/proper/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/proper/errors" {
+			htmlPage(w, "Understanding 404 errors", "<p>What a missing page means.</p>")
+			return
+		}
+		http.NotFound(w, r)
+	})
+
+	cases := []struct {
+		path   string
+		likely bool
+	}{
+		{"/worded/missing", true},
+		{"/worded/shipping", false},
+		{"/plain/missing", true},
+		{"/plain/shipping", false},
+		{"/proper/errors", false},
+	}
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	d := &Soft404Detector{Client: srv.Client()}
+	for _, tc := range cases {
+		res := d.Check(context.Background(), srv.URL+tc.path)
+		if res.Likely != tc.likely {
+			t.Errorf("%s: likely=%v confidence=%.2f, want likely=%v", tc.path, res.Likely, res.Confidence, tc.likely)
+		}
+	}
+}
+
+func TestSoft404Detector_gate(t *testing.T) {
+	var pageHits atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/robots.txt", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("User-agent: *\nDisallow: /private\n"))
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		pageHits.Add(1)
+		htmlPage(w, "Page not found", "")
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	gate := &FetchGate{Robots: NewRobotsCache(srv.Client(), DefaultRobotsConfig()), Obey: true}
+	d := &Soft404Detector{Client: srv.Client(), Gate: gate}
+	if res := d.Check(context.Background(), srv.URL+"/private/missing"); res.Likely || pageHits.Load() != 0 {
+		t.Fatalf("expected a disallowed page not to be fetched, got %+v after %d requests", res, pageHits.Load())
+	}
+	if res := d.Check(context.Background(), srv.URL+"/public/missing"); !res.Likely {
+		t.Fatalf("expected an allowed page to be examined, got %+v", res)
+	}
+}
+
+func TestSoft404Detector_decodesBody(t *testing.T) {
+	// A UTF-16 error page only reads as "not found" once decoded.
+	page := "<html><head><title>Page not found</title></head><body><p>Sorry.</p></body></html>"
+	body := []byte{0xFF, 0xFE}
+	for _, r := range utf16.Encode([]rune(page)) {
+		body = append(body, byte(r), byte(r>>8))
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/gone" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-16le")
+		w.Write(body)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	d := &Soft404Detector{Client: srv.Client()}
+	if res := d.Check(context.Background(), srv.URL+"/gone"); res.Confidence == 0 {
+		t.Fatalf("expected the decoded title to count, got %+v", res)
+	}
+}
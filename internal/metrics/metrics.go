@@ -15,7 +15,7 @@ var (
 			Help:    "Duration of analyzer strategies in seconds.",
 			Buckets: prometheus.DefBuckets,
 		},
-		[]string{"strategy"},
+		[]string{"strategy", "outcome"},
 	)
 
 	analyzeTotalDuration = prometheus.NewHistogram(
@@ -25,23 +25,99 @@ var (
 			Buckets: prometheus.DefBuckets,
 		},
 	)
+
+	linkCacheHits = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "analyzer_link_cache_hits_total",
+			Help: "Link checks answered from the link-check cache.",
+		},
+	)
+
+	linkCacheMisses = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "analyzer_link_cache_misses_total",
+			Help: "Link checks not found in the link-check cache.",
+		},
+	)
+
+	linkCheckQueueDepth = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "analyzer_link_check_queue_depth",
+			Help: "Link checks waiting for a global or per-host slot.",
+		},
+	)
 )
 
 func RegisterPrometheus() {
 	prometheus.MustRegister(strategyDuration)
 	prometheus.MustRegister(analyzeTotalDuration)
+	prometheus.MustRegister(linkCacheHits, linkCacheMisses)
+	prometheus.MustRegister(linkCheckQueueDepth)
 }
 
 func Handler() http.Handler {
 	return promhttp.Handler()
 }
 
-// ObserveStrategyDuration records the duration of a single strategy execution.
-func ObserveStrategyDuration(strategy string, d time.Duration) {
-	strategyDuration.WithLabelValues(strategy).Observe(d.Seconds())
+// Sink receives analyzer measurements so callers can swap the backend in tests
+// or embed the analyzer without Prometheus. Besides the analysis durations it
+// receives the link-check cache lookups and scheduler queue changes made on
+// the analyzer's behalf.
+type Sink interface {
+	ObserveStrategyDuration(strategy, outcome string, d time.Duration)
+	ObserveAnalyzeTotalDuration(d time.Duration)
+	ObserveLinkCacheLookup(hit bool)
+	AddLinkCheckQueueDepth(delta int)
+}
+
+// PrometheusSink records measurements in the package's Prometheus collectors.
+type PrometheusSink struct{}
+
+func (PrometheusSink) ObserveStrategyDuration(strategy, outcome string, d time.Duration) {
+	ObserveStrategyDuration(strategy, outcome, d)
+}
+
+func (PrometheusSink) ObserveAnalyzeTotalDuration(d time.Duration) {
+	ObserveAnalyzeTotalDuration(d)
+}
+
+func (PrometheusSink) ObserveLinkCacheLookup(hit bool) {
+	ObserveLinkCacheLookup(hit)
+}
+
+func (PrometheusSink) AddLinkCheckQueueDepth(delta int) {
+	AddLinkCheckQueueDepth(delta)
+}
+
+// OrDefault returns s, or PrometheusSink when s is nil.
+func OrDefault(s Sink) Sink {
+	if s == nil {
+		return PrometheusSink{}
+	}
+	return s
+}
+
+// ObserveStrategyDuration records the duration of a single strategy execution
+// labelled with how it ended (ok, failed or timed_out).
+func ObserveStrategyDuration(strategy, outcome string, d time.Duration) {
+	strategyDuration.WithLabelValues(strategy, outcome).Observe(d.Seconds())
 }
 
 // ObserveAnalyzeTotalDuration records the total duration of a full analyze task.
 func ObserveAnalyzeTotalDuration(d time.Duration) {
 	analyzeTotalDuration.Observe(d.Seconds())
 }
+
+// ObserveLinkCacheLookup counts a link-check cache hit or miss.
+func ObserveLinkCacheLookup(hit bool) {
+	if hit {
+		linkCacheHits.Inc()
+		return
+	}
+	linkCacheMisses.Inc()
+}
+
+// AddLinkCheckQueueDepth adjusts the number of link checks waiting for a slot.
+func AddLinkCheckQueueDepth(delta int) {
+	linkCheckQueueDepth.Add(float64(delta))
+}
@@ -5,18 +5,357 @@ type HeadingCount struct {
 	Count int `json:"count"`
 }
 
+// LinkStats counts link occurrences. Unchecked links were not probed, because
+// link checking was off or time ran out, and are included in Internal or
+// External. Inaccessible links are further split into Broken ones and those
+// Blocked by bot protection; links behind a login or rate limit are neither.
+// DisallowedByRobots counts links robots.txt asks the analyzer not to fetch;
+// when those are skipped they count as neither accessible nor inaccessible.
+// AnalyzeResult.Links covers anchors only; LinkKinds has the rest.
 type LinkStats struct {
-	Internal int `json:"internal"`
-	External int `json:"external"`
+	Internal     int `json:"internal"`
+	External     int `json:"external"`
 	Inaccessible int `json:"inaccessible"`
+	Unchecked    int `json:"unchecked,omitempty"`
+	Broken       int `json:"broken"`
+	Blocked      int `json:"blocked"`
+	Soft404      int `json:"soft_404,omitempty"`
+	// DisallowedByRobots is reported with the robots report_only and obey policies.
+	DisallowedByRobots int           `json:"disallowed_by_robots,omitempty"`
+	Breakdown          LinkBreakdown `json:"breakdown"`
+}
+
+// LinkBreakdown counts checked link occurrences per LinkCheck.Category.
+type LinkBreakdown struct {
+	OK                 int `json:"ok,omitempty"`
+	Redirect           int `json:"redirect,omitempty"`
+	ClientError        int `json:"client_error,omitempty"`
+	ServerError        int `json:"server_error,omitempty"`
+	AuthRequired       int `json:"auth_required,omitempty"`
+	RateLimited        int `json:"rate_limited,omitempty"`
+	Timeout            int `json:"timeout,omitempty"`
+	DNSError           int `json:"dns_error,omitempty"`
+	TLSError           int `json:"tls_error,omitempty"`
+	ConnectionError    int `json:"connection_error,omitempty"`
+	BrokenFragment     int `json:"broken_fragment,omitempty"`
+	DisallowedByRobots int `json:"disallowed_by_robots,omitempty"`
+}
+
+// Add counts n occurrences of category; unknown categories are ignored.
+func (b *LinkBreakdown) Add(category string, n int) {
+	switch category {
+	case LinkOK:
+		b.OK += n
+	case LinkRedirect:
+		b.Redirect += n
+	case LinkClientError:
+		b.ClientError += n
+	case LinkServerError:
+		b.ServerError += n
+	case LinkAuthRequired:
+		b.AuthRequired += n
+	case LinkRateLimited:
+		b.RateLimited += n
+	case LinkTimeout:
+		b.Timeout += n
+	case LinkDNSError:
+		b.DNSError += n
+	case LinkTLSError:
+		b.TLSError += n
+	case LinkConnectionError:
+		b.ConnectionError += n
+	case LinkBrokenFragment:
+		b.BrokenFragment += n
+	case LinkDisallowedByRobots:
+		b.DisallowedByRobots += n
+	}
+}
+
+// Link kinds reported in LinkDetail.Kind. Anchors are navigational links;
+// the others are resources the page embeds or hints at.
+const (
+	LinkKindAnchor     = "anchor"
+	LinkKindImage      = "image"
+	LinkKindScript     = "script"
+	LinkKindStylesheet = "stylesheet"
+	LinkKindMedia      = "media"
+	LinkKindFrame      = "frame"
+	LinkKindPreload    = "preload"
+	LinkKindOther      = "other"
+)
+
+// Site relations reported in LinkDetail.Site, describing where a link points
+// relative to the analyzed page.
+const (
+	SiteSameHost  = "same_host"
+	SiteSameSite  = "same_site"
+	SiteCrossSite = "cross_site"
+)
+
+// Link check categories reported in LinkCheck.Category.
+const (
+	LinkOK              = "ok"
+	LinkRedirect        = "redirect"
+	LinkClientError     = "client_error"
+	LinkServerError     = "server_error"
+	LinkAuthRequired    = "auth_required"
+	LinkRateLimited     = "rate_limited"
+	LinkTimeout         = "timeout"
+	LinkDNSError        = "dns_error"
+	LinkTLSError        = "tls_error"
+	LinkConnectionError = "connection_error"
+	LinkBrokenFragment  = "broken_fragment"
+	// LinkDisallowedByRobots marks links not requested because robots.txt
+	// disallows them.
+	LinkDisallowedByRobots = "disallowed_by_robots"
+)
+
+// LinkCheck is the outcome of probing one link. Category classifies it, and
+// Blocked marks responses that look like bot protection rather than a broken
+// link. RedirectURL is set when the link redirected elsewhere; Error explains
+// why an inaccessible link failed. Checkers that only report accessibility
+// leave Category empty.
+// Attempts counts the requests made including retries. Cached results report
+// the latency and attempts of the check that produced them.
+type LinkCheck struct {
+	Accessible  bool   `json:"accessible"`
+	Category    string `json:"category,omitempty"`
+	Blocked     bool   `json:"blocked,omitempty"`
+	StatusCode  int    `json:"status_code,omitempty"`
+	Error       string `json:"error,omitempty"`
+	RedirectURL string `json:"redirect_url,omitempty"`
+	LatencyMs   int64  `json:"latency_ms"`
+	Attempts    int    `json:"attempts,omitempty"`
+	Cached      bool   `json:"cached,omitempty"`
+	// Soft404 flags a page that answered successfully but looks like a "not
+	// found" page; Soft404Confidence is the detector's score from 0 to 1.
+	Soft404           bool    `json:"soft_404,omitempty"`
+	Soft404Confidence float64 `json:"soft_404_confidence,omitempty"`
+	// RobotsDisallowed is set when robots.txt disallows the link for the
+	// analyzer, whether or not it was requested anyway.
+	RobotsDisallowed bool `json:"robots_disallowed,omitempty"`
+}
+
+// LinkDetail describes one unique link of a given kind found in the document.
+// URL is the normalized link without its fragment. Href, Text, Element and
+// Attribute come from its first occurrence, and Variants lists the other raw
+// hrefs that normalized to it. Fragments lists the distinct #fragments it was
+// referenced with, BrokenFragments those the target page lacks, and SamePage
+// marks links into the analyzed document itself. For anchors, Rel collects
+// the rel values of all occurrences, and Target, Hreflang and Download
+// report the first occurrence that sets them. Site relates the link to the
+// page, and Internal reflects the scope the analysis counted as internal. The
+// check fields are only meaningful when Checked is set.
+type LinkDetail struct {
+	URL             string   `json:"url"`
+	Kind            string   `json:"kind"`
+	Href            string   `json:"href"`
+	Variants        []string `json:"variants,omitempty"`
+	Fragments       []string `json:"fragments,omitempty"`
+	BrokenFragments []string `json:"broken_fragments,omitempty"`
+	SamePage        bool     `json:"same_page,omitempty"`
+	Text            string   `json:"text,omitempty"`
+	Element         string   `json:"element"`
+	Attribute       string   `json:"attribute"`
+	Rel             []string `json:"rel,omitempty"`
+	Target          string   `json:"target,omitempty"`
+	Hreflang        string   `json:"hreflang,omitempty"`
+	Download        bool     `json:"download,omitempty"`
+	Occurrences     int      `json:"occurrences"`
+	Site            string   `json:"site"`
+	Internal        bool     `json:"internal"`
+	Checked         bool     `json:"checked"`
+	LinkCheck
+}
+
+// DomainStats summarizes the external links of every kind pointing at one
+// registrable domain (or host, for IP addresses). Links and Inaccessible count
+// occurrences, UniqueURLs distinct URLs. HTTPS is set when every link to the
+// domain uses https, judged by where checked links ended up after redirects.
+type DomainStats struct {
+	Domain       string `json:"domain"`
+	Links        int    `json:"links"`
+	UniqueURLs   int    `json:"unique_urls"`
+	Inaccessible int    `json:"inaccessible"`
+	HTTPS        bool   `json:"https"`
+}
+
+// LinkSample describes a sampled link check, counted in unique URLs of every
+// kind. Size of the Population URLs were drawn and Checked of them finished;
+// Inaccessible of those failed. EstimatedInaccessible extrapolates that to
+// the population, with Low and High bounding it at the Confidence level.
+type LinkSample struct {
+	Population            int     `json:"population"`
+	Size                  int     `json:"size"`
+	Checked               int     `json:"checked"`
+	Inaccessible          int     `json:"inaccessible"`
+	EstimatedInaccessible int     `json:"estimated_inaccessible"`
+	Low                   int     `json:"low"`
+	High                  int     `json:"high"`
+	Confidence            float64 `json:"confidence"`
+}
+
+// LinkAudit summarizes the attributes of the document's anchors, counting
+// occurrences. UnsafeTargetBlank counts target="_blank" links without
+// rel="noopener" or "noreferrer", which implies it. Findings lists the links
+// worth a look, once per link and issue.
+type LinkAudit struct {
+	Nofollow          int           `json:"nofollow"`
+	UGC               int           `json:"ugc"`
+	Sponsored         int           `json:"sponsored"`
+	Noopener          int           `json:"noopener"`
+	Noreferrer        int           `json:"noreferrer"`
+	TargetBlank       int           `json:"target_blank"`
+	UnsafeTargetBlank int           `json:"unsafe_target_blank"`
+	Hreflang          int           `json:"hreflang"`
+	Download          int           `json:"download"`
+	Findings          []LinkFinding `json:"findings,omitempty"`
+}
+
+// Link audit issues reported in LinkFinding.Issue.
+const (
+	FindingUnsafeTargetBlank = "target_blank_without_noopener"
+	FindingHreflang          = "hreflang"
+	FindingDownload          = "download"
+)
+
+// LinkFinding is one audited link. Href is its first flagged occurrence and
+// Value the attribute value behind the finding, such as the hreflang
+// language or the suggested download file name.
+type LinkFinding struct {
+	Issue       string `json:"issue"`
+	URL         string `json:"url"`
+	Href        string `json:"href"`
+	Value       string `json:"value,omitempty"`
+	Occurrences int    `json:"occurrences"`
+}
+
+// SEOInfo reports the page's search-engine metadata. Robots holds the
+// directives of <meta name="robots"> and XRobotsTag those of the response
+// header, lowercased; agent-specific header directives keep their
+// "agent: " prefix. Indexable is false when a directive or a canonical link
+// pointing elsewhere keeps the page out of search indexes; the findings
+// marked BlocksIndexing say which.
+type SEOInfo struct {
+	Title       *SEOText     `json:"title,omitempty"`
+	Description *SEOText     `json:"description,omitempty"`
+	Keywords    []string     `json:"keywords,omitempty"`
+	Viewport    string       `json:"viewport,omitempty"`
+	Canonical   string       `json:"canonical,omitempty"`
+	Robots      []string     `json:"robots,omitempty"`
+	XRobotsTag  []string     `json:"x_robots_tag,omitempty"`
+	Indexable   bool         `json:"indexable"`
+	Findings    []SEOFinding `json:"findings,omitempty"`
+}
+
+// SEOText is a text shown on search result pages, with its length in
+// characters and its estimated rendered width in pixels.
+type SEOText struct {
+	Text   string `json:"text"`
+	Chars  int    `json:"chars"`
+	Pixels int    `json:"pixels"`
+}
+
+// SEO fields reported in SEOFinding.Field.
+const (
+	SEOFieldTitle       = "title"
+	SEOFieldDescription = "description"
+	SEOFieldKeywords    = "keywords"
+	SEOFieldViewport    = "viewport"
+	SEOFieldCanonical   = "canonical"
+	SEOFieldRobots      = "robots"
+	SEOFieldXRobotsTag  = "x_robots_tag"
+)
+
+// SEO issues reported in SEOFinding.Issue.
+const (
+	SEOMissing       = "missing"
+	SEOEmpty         = "empty"
+	SEODuplicate     = "duplicate"
+	SEOTooShort      = "too_short"
+	SEOTooLong       = "too_long"
+	SEOInvalid       = "invalid"
+	SEONoindex       = "noindex"
+	SEOCanonicalized = "canonicalized"
+)
+
+// SEOFinding is one problem with the page's metadata. Value is what the
+// finding is about, such as the duplicated text or the canonical URL; Chars
+// and Pixels are set for length findings.
+type SEOFinding struct {
+	Field          string `json:"field"`
+	Issue          string `json:"issue"`
+	Value          string `json:"value,omitempty"`
+	Chars          int    `json:"chars,omitempty"`
+	Pixels         int    `json:"pixels,omitempty"`
+	BlocksIndexing bool   `json:"blocks_indexing,omitempty"`
+}
+
+// EncodingInfo describes the character encoding the page was decoded from.
+// Source is "header" when taken from the Content-Type charset, "bom" or "meta"
+// when declared by the document itself, and "default" when none was declared.
+type EncodingInfo struct {
+	Charset string `json:"charset"`
+	Source  string `json:"source"`
+}
+
+// Redirect is one hop of the redirect chain followed while fetching the page.
+type Redirect struct {
+	URL        string `json:"url"`
+	StatusCode int    `json:"status_code"`
+	Location   string `json:"location"`
+}
+
+// FetchInfo describes the HTTP exchange that produced the analyzed document.
+// ContentLength is -1 when the server did not announce it; BytesRead is what
+// was actually consumed, and Truncated is set when the body hit the size cap.
+type FetchInfo struct {
+	FinalURL      string            `json:"final_url"`
+	Redirects     []Redirect        `json:"redirects"`
+	StatusCode    int               `json:"status_code"`
+	Headers       map[string]string `json:"headers"`
+	ContentLength int64             `json:"content_length"`
+	Protocol      string            `json:"protocol"`
+	DurationMs    int64             `json:"duration_ms"`
+	BytesRead     int64             `json:"bytes_read"`
+	Truncated     bool              `json:"truncated"`
+}
+
+// Strategy statuses reported in StrategyStatus.Status.
+const (
+	StrategyOK       = "ok"
+	StrategyFailed   = "failed"
+	StrategyTimedOut = "timed_out"
+	StrategySkipped  = "skipped"
+)
+
+// StrategyStatus reports how one analysis strategy fared.
+type StrategyStatus struct {
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
 }
 
 // AnalyzeResult is populated by AnalyzerStrategy implementations
 // and returned by AnalyzePage.
 type AnalyzeResult struct {
-	HTMLVersion string        `json:"html_version"`
-	Title       string        `json:"title"`
-	Headings    []HeadingCount `json:"headings"`
-	Links       LinkStats     `json:"links"`
-	LoginForm   bool          `json:"login_form"`
+	HTMLVersion string               `json:"html_version"`
+	Title       string               `json:"title"`
+	Headings    []HeadingCount       `json:"headings"`
+	Links       LinkStats            `json:"links"`
+	LinkKinds   map[string]LinkStats `json:"link_kinds,omitempty"`
+	LinkDetails []LinkDetail         `json:"link_details,omitempty"`
+	LinkAudit   *LinkAudit           `json:"link_audit,omitempty"`
+	// LinkCheckMode is the link-check mode the analysis used.
+	LinkCheckMode string      `json:"link_check_mode,omitempty"`
+	LinkSample    *LinkSample `json:"link_sample,omitempty"`
+	// ExternalDomains groups the links not counted as internal by domain.
+	ExternalDomains []DomainStats    `json:"external_domains,omitempty"`
+	SEO             *SEOInfo         `json:"seo,omitempty"`
+	LoginForm       bool             `json:"login_form"`
+	Encoding        EncodingInfo     `json:"encoding"`
+	Fetch           FetchInfo        `json:"fetch"`
+	Strategies      []StrategyStatus `json:"strategies"`
+	Warnings        []string         `json:"warnings,omitempty"`
 }
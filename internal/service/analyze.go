@@ -15,5 +15,5 @@ var (
 )
 
 func AnalyzePage(ctx context.Context, url string) (*model.AnalyzeResult, error) {
-	return analyzer.AnalyzePage(ctx, url)
+	return analyzer.AnalyzePage(ctx, url, analyzer.Options{})
 }
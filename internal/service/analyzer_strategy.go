@@ -6,6 +6,8 @@ import (
 
 type AnalyzerStrategy = analyzer.AnalyzerStrategy
 
+type ContextStrategy = analyzer.ContextStrategy
+
 type HTMLVersionStrategy = analyzer.HTMLVersionStrategy
 
 type TitleStrategy = analyzer.TitleStrategy
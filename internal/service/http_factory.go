@@ -10,4 +10,6 @@ type DefaultHTTPClientFactory = factory.DefaultHTTPClientFactory
 
 type LinkChecker = factory.LinkChecker
 
+type ContextLinkChecker = factory.ContextLinkChecker
+
 type DefaultLinkChecker = factory.DefaultLinkChecker
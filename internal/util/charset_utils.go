@@ -0,0 +1,139 @@
+package util
+
+import (
+	"bytes"
+	"mime"
+	"strings"
+	"unicode/utf8"
+	"web-analyzer-go/internal/model"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+)
+
+// Charset sources reported in model.EncodingInfo.Source.
+const (
+	CharsetSourceHeader  = "header"
+	CharsetSourceBOM     = "bom"
+	CharsetSourceMeta    = "meta"
+	CharsetSourceDefault = "default"
+)
+
+// prescanLimit mirrors the WHATWG prescan window for <meta charset>.
+const prescanLimit = 1024
+
+var boms = []struct {
+	bom []byte
+	enc string
+}{
+	{[]byte{0xEF, 0xBB, 0xBF}, "utf-8"},
+	{[]byte{0xFE, 0xFF}, "utf-16be"},
+	{[]byte{0xFF, 0xFE}, "utf-16le"},
+}
+
+// DecodeHTML transcodes an HTML document to UTF-8. The encoding is taken from
+// a byte order mark, the Content-Type charset parameter or a <meta> declaration
+// in the first 1KB, in that order. Documents without any declaration are
+// treated as UTF-8 when valid, ignoring a truncated final character, and
+// windows-1252 otherwise.
+func DecodeHTML(body []byte, contentType string) ([]byte, model.EncodingInfo) {
+	for _, b := range boms {
+		if bytes.HasPrefix(body, b.bom) {
+			return transcode(body[len(b.bom):], b.enc, CharsetSourceBOM)
+		}
+	}
+
+	if _, params, err := mime.ParseMediaType(contentType); err == nil {
+		if cs, ok := params["charset"]; ok {
+			if _, name := charset.Lookup(cs); name != "" {
+				return transcode(body, name, CharsetSourceHeader)
+			}
+		}
+	}
+
+	if label := prescanMetaCharset(body); label != "" {
+		if _, name := charset.Lookup(label); name != "" {
+			// A document cannot declare itself UTF-16 from inside an ASCII-compatible prefix.
+			if strings.HasPrefix(name, "utf-16") {
+				name = "utf-8"
+			}
+			return transcode(body, name, CharsetSourceMeta)
+		}
+	}
+
+	if trimmed := trimIncompleteRune(body); utf8.Valid(trimmed) {
+		return trimmed, model.EncodingInfo{Charset: "utf-8", Source: CharsetSourceDefault}
+	}
+	return transcode(body, "windows-1252", CharsetSourceDefault)
+}
+
+// trimIncompleteRune drops a final multi-byte UTF-8 sequence cut short, as
+// happens when the body is truncated at the size cap, so that it does not
+// make an otherwise valid document look like another encoding.
+func trimIncompleteRune(body []byte) []byte {
+	for k := 1; k <= utf8.UTFMax-1 && k <= len(body); k++ {
+		if utf8.RuneStart(body[len(body)-k]) {
+			if !utf8.FullRune(body[len(body)-k:]) {
+				return body[:len(body)-k]
+			}
+			break
+		}
+	}
+	return body
+}
+
+func transcode(body []byte, name, source string) ([]byte, model.EncodingInfo) {
+	info := model.EncodingInfo{Charset: name, Source: source}
+	if name == "utf-8" {
+		return body, info
+	}
+	enc, _ := charset.Lookup(name)
+	if enc == nil {
+		return body, info
+	}
+	decoded, err := enc.NewDecoder().Bytes(body)
+	if err != nil {
+		return body, info
+	}
+	return decoded, info
+}
+
+// prescanMetaCharset looks for <meta charset> or an http-equiv Content-Type
+// declaration in the first bytes of the document and returns its label.
+func prescanMetaCharset(body []byte) string {
+	if len(body) > prescanLimit {
+		body = body[:prescanLimit]
+	}
+	z := html.NewTokenizer(bytes.NewReader(body))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return ""
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			if !bytes.EqualFold(name, []byte("meta")) {
+				continue
+			}
+			var httpEquiv, content string
+			for hasAttr {
+				var key, val []byte
+				key, val, hasAttr = z.TagAttr()
+				switch strings.ToLower(string(key)) {
+				case "charset":
+					return strings.TrimSpace(string(val))
+				case "http-equiv":
+					httpEquiv = strings.ToLower(strings.TrimSpace(string(val)))
+				case "content":
+					content = string(val)
+				}
+			}
+			if httpEquiv == "content-type" && content != "" {
+				if _, params, err := mime.ParseMediaType(content); err == nil {
+					if cs, ok := params["charset"]; ok {
+						return cs
+					}
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package util
+
+import (
+	"slices"
+	"strings"
+	"web-analyzer-go/internal/model"
+
+	"golang.org/x/net/html"
+)
+
+// FragmentTargets returns the names a URL fragment can scroll to in the
+// document: every id attribute and the name of every legacy <a name> anchor.
+func FragmentTargets(n *html.Node) map[string]bool {
+	targets := make(map[string]bool)
+	var walk func(*html.Node)
+	walk = func(node *html.Node) {
+		if node.Type == html.ElementNode {
+			for _, a := range node.Attr {
+				if a.Key == "id" || (a.Key == "name" && node.Data == "a") {
+					if a.Val != "" {
+						targets[a.Val] = true
+					}
+				}
+			}
+		}
+		for c := node.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return targets
+}
+
+// FragmentFound reports whether fragment resolves in a document with the
+// given targets. Empty fragments and "top" always do, as browsers scroll to
+// the top of the page for them.
+func FragmentFound(targets map[string]bool, fragment string) bool {
+	return fragment == "" || strings.EqualFold(fragment, "top") || targets[fragment]
+}
+
+// NeedsFragmentTargets reports whether validating l's fragments requires the
+// targets of the page it points to.
+func NeedsFragmentTargets(l model.LinkDetail) bool {
+	return slices.ContainsFunc(l.Fragments, func(f string) bool { return !FragmentFound(nil, f) })
+}
+
+// MarkFragment records the outcome of validating l's fragments against
+// targets. Any missing target turns an accessible link into a broken
+// fragment, listing the missing ones in BrokenFragments.
+func MarkFragment(l *model.LinkDetail, targets map[string]bool) {
+	var missing []string
+	for _, f := range l.Fragments {
+		if !FragmentFound(targets, f) {
+			missing = append(missing, f)
+		}
+	}
+	if len(missing) == 0 {
+		return
+	}
+	l.Accessible = false
+	l.Category = model.LinkBrokenFragment
+	l.BrokenFragments = missing
+	l.Error = "fragment #" + strings.Join(missing, ", #") + " not found"
+}
+
+// CheckSamePageFragments validates same-page links against the document
+// they appear in, without any network calls, and marks them Checked.
+func CheckSamePageFragments(doc *html.Node, links []model.LinkDetail) {
+	var targets map[string]bool
+	for i := range links {
+		l := &links[i]
+		if !l.SamePage {
+			continue
+		}
+		if targets == nil {
+			targets = FragmentTargets(doc)
+		}
+		l.Checked = true
+		l.LinkCheck = model.LinkCheck{Accessible: true, Category: model.LinkOK}
+		MarkFragment(l, targets)
+	}
+}
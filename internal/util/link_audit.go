@@ -0,0 +1,125 @@
+package util
+
+import (
+	"net/url"
+	"slices"
+	"strings"
+	"web-analyzer-go/internal/model"
+
+	"golang.org/x/net/html"
+)
+
+// anchorAttrs are the attributes of one anchor occurrence the audit reads.
+type anchorAttrs struct {
+	rel        []string
+	target     string
+	hreflang   string
+	download   bool
+	downloadAs string
+}
+
+func readAnchorAttrs(node *html.Node) anchorAttrs {
+	var a anchorAttrs
+	for _, attr := range node.Attr {
+		switch attr.Key {
+		case "rel":
+			for _, v := range strings.Fields(strings.ToLower(attr.Val)) {
+				if !slices.Contains(a.rel, v) {
+					a.rel = append(a.rel, v)
+				}
+			}
+		case "target":
+			a.target = strings.TrimSpace(attr.Val)
+		case "hreflang":
+			a.hreflang = strings.TrimSpace(attr.Val)
+		case "download":
+			a.download, a.downloadAs = true, strings.TrimSpace(attr.Val)
+		}
+	}
+	return a
+}
+
+func (a anchorAttrs) hasRel(v string) bool {
+	return slices.Contains(a.rel, v)
+}
+
+func (a anchorAttrs) targetBlank() bool {
+	return strings.EqualFold(a.target, "_blank")
+}
+
+// unsafeTargetBlank reports a new-tab link that leaves window.opener to the
+// target page.
+func (a anchorAttrs) unsafeTargetBlank() bool {
+	return a.targetBlank() && !a.hasRel("noopener") && !a.hasRel("noreferrer")
+}
+
+// mergeInto adds one occurrence's attributes to its link entry.
+func (a anchorAttrs) mergeInto(l *model.LinkDetail) {
+	for _, v := range a.rel {
+		if !slices.Contains(l.Rel, v) {
+			l.Rel = append(l.Rel, v)
+		}
+	}
+	if l.Target == "" {
+		l.Target = a.target
+	}
+	if l.Hreflang == "" {
+		l.Hreflang = a.hreflang
+	}
+	l.Download = l.Download || a.download
+}
+
+// AuditLinks counts the rel, target, hreflang and download attributes of the
+// document's http(s) anchors and lists the links that open a new tab without
+// noopener or carry hreflang or download. Links resolve as in ExtractLinksWith.
+func AuditLinks(n *html.Node, page *url.URL, norm URLNormalizer) model.LinkAudit {
+	var audit model.LinkAudit
+	index := make(map[[2]string]int)
+	flag := func(issue string, ref linkRef, value string) {
+		key := [2]string{issue, ref.url}
+		if i, ok := index[key]; ok {
+			audit.Findings[i].Occurrences++
+			return
+		}
+		index[key] = len(audit.Findings)
+		audit.Findings = append(audit.Findings, model.LinkFinding{
+			Issue: issue, URL: ref.url, Href: ref.raw, Value: value, Occurrences: 1,
+		})
+	}
+	walkLinks(n, page, norm, func(ref linkRef) {
+		if linkKind(ref.node) != model.LinkKindAnchor {
+			return
+		}
+		a := readAnchorAttrs(ref.node)
+		for _, rel := range a.rel {
+			switch rel {
+			case "nofollow":
+				audit.Nofollow++
+			case "ugc":
+				audit.UGC++
+			case "sponsored":
+				audit.Sponsored++
+			case "noopener":
+				audit.Noopener++
+			case "noreferrer":
+				audit.Noreferrer++
+			}
+		}
+		if a.targetBlank() {
+			audit.TargetBlank++
+		}
+		if a.unsafeTargetBlank() {
+			audit.UnsafeTargetBlank++
+			flag(model.FindingUnsafeTargetBlank, ref, "")
+		}
+		if a.hreflang != "" {
+			audit.Hreflang++
+			flag(model.FindingHreflang, ref, a.hreflang)
+		}
+		if a.download {
+			audit.Download++
+			flag(model.FindingDownload, ref, a.downloadAs)
+		}
+	})
+	return audit
+}
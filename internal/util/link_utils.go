@@ -1,27 +1,117 @@
 package util
 
 import (
+	"context"
 	"net/url"
+	"slices"
+	"strings"
 	"sync"
+	"web-analyzer-go/internal/model"
 
 	"golang.org/x/net/html"
 )
 
+// CountLinks classifies the http(s) anchor links in the document as internal
+// or external and counts those isAccessible rejects as inaccessible. A nil
+// isAccessible skips the accessibility checks entirely.
 func CountLinks(n *html.Node, base *url.URL, isAccessible func(string) bool) (internal, external, inaccessible int) {
-	// First pass: collect absolute URLs and track occurrences + internal/external classification
-	type linkAgg struct {
-		isInternal  bool
-		occurrences int
+	var check func(context.Context, string) bool
+	if isAccessible != nil {
+		check = func(_ context.Context, link string) bool { return isAccessible(link) }
 	}
-	links := make(map[string]*linkAgg)
+	stats, _ := CountLinksContext(context.Background(), n, base, check)
+	return stats.Internal, stats.External, stats.Inaccessible
+}
+
+// CountLinksContext is CountLinks with cancellation; see CheckLinks for how
+// cancellation affects the returned stats.
+func CountLinksContext(ctx context.Context, n *html.Node, base *url.URL, isAccessible func(context.Context, string) bool) (model.LinkStats, error) {
+	links := ExtractLinks(n, base)
+	var check func(context.Context, string) model.LinkCheck
+	if isAccessible != nil {
+		check = func(ctx context.Context, link string) model.LinkCheck {
+			return model.LinkCheck{Accessible: isAccessible(ctx, link)}
+		}
+	}
+	err := CheckLinks(ctx, links, check)
+	return SummarizeLinksByKind(links)[model.LinkKindAnchor], err
+}
+
+// ExtractLinks is ExtractLinksWith using DefaultURLNormalizer.
+func ExtractLinks(n *html.Node, page *url.URL) []model.LinkDetail {
+	return ExtractLinksWith(n, page, DefaultURLNormalizer())
+}
+
+// ExtractLinksWith returns one entry per unique link and kind referenced by an
+// href or src attribute, in document order. page is the document's own URL,
+// after redirects; relative links resolve against the document's <base href>
+// when it has one. Links are deduplicated on their http(s) URL as normalized
+// by norm, which drops the fragment; the distinct fragments they were
+// referenced with are kept on the entry for validation. Links on the same host
+// as page are marked internal.
+func ExtractLinksWith(n *html.Node, page *url.URL, norm URLNormalizer) []model.LinkDetail {
+	pageURL := norm.Normalize(page)
+	var links []model.LinkDetail
+	index := make(map[[2]string]int)
+	walkLinks(n, page, norm, func(ref linkRef) {
+		kind := linkKind(ref.node)
+		key := [2]string{kind, ref.url}
+		i, ok := index[key]
+		if ok {
+			links[i].Occurrences++
+			if ref.raw != links[i].Href && !slices.Contains(links[i].Variants, ref.raw) {
+				links[i].Variants = append(links[i].Variants, ref.raw)
+			}
+		} else {
+			site := SiteRelation(ref.abs, page)
+			i = len(links)
+			index[key] = i
+			links = append(links, model.LinkDetail{
+				URL:         ref.url,
+				Kind:        kind,
+				Href:        ref.raw,
+				Text:        linkText(ref.node),
+				Element:     ref.node.Data,
+				Attribute:   ref.attr,
+				Occurrences: 1,
+				Site:        site,
+				Internal:    site == model.SiteSameHost,
+			})
+		}
+		if f := ref.abs.Fragment; f != "" && !slices.Contains(links[i].Fragments, f) {
+			links[i].Fragments = append(links[i].Fragments, f)
+			links[i].SamePage = ref.url == pageURL
+		}
+		if kind == model.LinkKindAnchor {
+			readAnchorAttrs(ref.node).mergeInto(&links[i])
+		}
+	})
+	return links
+}
+
+// linkRef is one http(s) reference found in a document: the element, the
+// attribute and its raw value, the absolute URL it resolves to and that URL
+// normalized.
+type linkRef struct {
+	node *html.Node
+	attr string
+	raw  string
+	abs  *url.URL
+	url  string
+}
 
+// walkLinks calls fn for every href or src attribute in document order that
+// resolves, against the document's <base href> or page, to an http(s) URL.
+func walkLinks(n *html.Node, page *url.URL, norm URLNormalizer, fn func(linkRef)) {
+	base := DocumentBase(n, page)
 	var walk func(*html.Node)
 	walk = func(node *html.Node) {
-		if node.Type == html.ElementNode {
-			link := ""
+		// <base href> sets how other links resolve; it is not a link itself.
+		if node.Type == html.ElementNode && node.Data != "base" {
+			attrKey, link := "", ""
 			for _, attr := range node.Attr {
 				if attr.Key == "href" || attr.Key == "src" {
-					link = attr.Val
+					attrKey, link = attr.Key, attr.Val
 					break
 				}
 			}
@@ -33,13 +123,7 @@ func CountLinks(n *html.Node, base *url.URL, isAccessible func(string) bool) (in
 						abs = base.ResolveReference(u)
 					}
 					if abs.Scheme == "http" || abs.Scheme == "https" {
-						key := abs.String()
-						agg, ok := links[key]
-						if !ok {
-							agg = &linkAgg{isInternal: abs.Host == base.Host}
-							links[key] = agg
-						}
-						agg.occurrences++
+						fn(linkRef{node: node, attr: attrKey, raw: link, abs: abs, url: norm.Normalize(abs)})
 					}
 				}
 			}
@@ -49,29 +133,135 @@ func CountLinks(n *html.Node, base *url.URL, isAccessible func(string) bool) (in
 		}
 	}
 	walk(n)
+}
 
-	if len(links) == 0 {
-		return 0, 0, 0
+// linkKind classifies the element referencing a link.
+func linkKind(node *html.Node) string {
+	switch node.Data {
+	case "a", "area":
+		return model.LinkKindAnchor
+	case "img":
+		return model.LinkKindImage
+	case "script":
+		return model.LinkKindScript
+	case "audio", "video", "track", "embed":
+		return model.LinkKindMedia
+	case "iframe", "frame":
+		return model.LinkKindFrame
+	case "input":
+		if strings.EqualFold(attrValue(node, "type"), "image") {
+			return model.LinkKindImage
+		}
+	case "source":
+		if node.Parent != nil && node.Parent.Data == "picture" {
+			return model.LinkKindImage
+		}
+		return model.LinkKindMedia
+	case "link":
+		for _, rel := range strings.Fields(strings.ToLower(attrValue(node, "rel"))) {
+			switch rel {
+			case "stylesheet":
+				return model.LinkKindStylesheet
+			case "preload", "prefetch", "modulepreload", "preconnect", "dns-prefetch", "prerender":
+				return model.LinkKindPreload
+			}
+		}
+	}
+	return model.LinkKindOther
+}
+
+func attrValue(node *html.Node, key string) string {
+	for _, a := range node.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// linkText returns the whitespace-collapsed text content of node, falling
+// back to an image's alt text for image-only links.
+func linkText(node *html.Node) string {
+	var b strings.Builder
+	var alt string
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		switch {
+		case n.Type == html.TextNode:
+			b.WriteString(n.Data)
+			b.WriteByte(' ')
+		case n.Type == html.ElementNode && n.Data == "img" && alt == "":
+			for _, a := range n.Attr {
+				if a.Key == "alt" {
+					alt = a.Val
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	for c := node.FirstChild; c != nil; c = c.NextSibling {
+		walk(c)
+	}
+	if text := strings.Join(strings.Fields(b.String()), " "); text != "" {
+		return text
+	}
+	return strings.Join(strings.Fields(alt), " ")
+}
+
+// CheckLinks probes every unique URL with check using a bounded worker pool
+// and records the outcomes in place on each entry for that URL. Links already
+// marked Checked are left alone; a nil check leaves the rest unchecked. Once
+// ctx is done no new checks are started and checks still in flight are
+// discarded, so only links that finished before that are marked Checked; the
+// context's error is returned.
+func CheckLinks(ctx context.Context, links []model.LinkDetail, check func(context.Context, string) model.LinkCheck) error {
+	if check == nil {
+		return nil
+	}
+
+	// The same URL may be referenced as several kinds; check it once.
+	byURL := make(map[string][]int)
+	var urls []string
+	for i, l := range links {
+		if l.Checked {
+			continue
+		}
+		if _, ok := byURL[l.URL]; !ok {
+			urls = append(urls, l.URL)
+		}
+		byURL[l.URL] = append(byURL[l.URL], i)
+	}
+
+	if len(urls) == 0 {
+		return nil
 	}
 
-	// Second pass: check accessibility concurrently for unique URLs
 	// Bounded worker pool to avoid unbounded concurrency
 	workerCount := 20
-	if workerCount > len(links) {
-		workerCount = len(links)
+	if workerCount > len(urls) {
+		workerCount = len(urls)
 	}
 	jobs := make(chan string, workerCount)
-	results := make(map[string]bool)
-	var mu sync.Mutex
 	var wg sync.WaitGroup
 
+	// Each worker writes only the entries for the URLs it received, so no
+	// locking is needed.
 	worker := func() {
 		defer wg.Done()
 		for u := range jobs {
-			ok := isAccessible(u)
-			mu.Lock()
-			results[u] = ok
-			mu.Unlock()
+			if ctx.Err() != nil {
+				continue // drain remaining jobs without checking them
+			}
+			result := check(ctx, u)
+			if ctx.Err() != nil {
+				continue // a check cut short by cancellation says nothing about the link
+			}
+			for _, i := range byURL[u] {
+				links[i].LinkCheck = result
+				links[i].Checked = true
+			}
 		}
 	}
 
@@ -80,24 +270,67 @@ func CountLinks(n *html.Node, base *url.URL, isAccessible func(string) bool) (in
 		go worker()
 	}
 
-	for u := range links {
-		jobs <- u
+enqueue:
+	for _, u := range urls {
+		select {
+		case jobs <- u:
+		case <-ctx.Done():
+			break enqueue
+		}
 	}
 	close(jobs)
 	wg.Wait()
+	return ctx.Err()
+}
 
-	// Aggregate counts with accessibility results and original occurrences
-	for u, agg := range links {
-		accessibleOK := results[u]
-		if !accessibleOK {
-			inaccessible += agg.occurrences
+// SummarizeLinks aggregates link occurrences into LinkStats. Checked links
+// that are not accessible count as inaccessible rather than internal or
+// external, and as broken unless they were blocked by bot protection or sit
+// behind a login or rate limit. Links skipped because of robots.txt are not
+// inaccessible. Links that were never checked count in Unchecked as well.
+func SummarizeLinks(links []model.LinkDetail) model.LinkStats {
+	var stats model.LinkStats
+	for _, l := range links {
+		if l.Checked {
+			stats.Breakdown.Add(l.Category, l.Occurrences)
+			if l.Soft404 {
+				stats.Soft404 += l.Occurrences
+			}
+			if l.RobotsDisallowed {
+				stats.DisallowedByRobots += l.Occurrences
+			}
+		}
+		switch {
+		case l.Checked && !l.Accessible && l.Category != model.LinkDisallowedByRobots:
+			stats.Inaccessible += l.Occurrences
+			switch {
+			case l.Blocked:
+				stats.Blocked += l.Occurrences
+			case l.Category != model.LinkAuthRequired && l.Category != model.LinkRateLimited:
+				stats.Broken += l.Occurrences
+			}
 			continue
+		case !l.Checked:
+			stats.Unchecked += l.Occurrences
 		}
-		if agg.isInternal {
-			internal += agg.occurrences
+		if l.Internal {
+			stats.Internal += l.Occurrences
 		} else {
-			external += agg.occurrences
+			stats.External += l.Occurrences
 		}
 	}
-	return
+	return stats
+}
+
+// SummarizeLinksByKind aggregates link occurrences per kind.
+func SummarizeLinksByKind(links []model.LinkDetail) map[string]model.LinkStats {
+	groups := make(map[string][]model.LinkDetail)
+	for _, l := range links {
+		groups[l.Kind] = append(groups[l.Kind], l)
+	}
+	stats := make(map[string]model.LinkStats, len(groups))
+	for kind, group := range groups {
+		stats[kind] = SummarizeLinks(group)
+	}
+	return stats
 }
@@ -0,0 +1,36 @@
+package util
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// IsHTMLMediaType reports whether the media type is one the analyzer can parse.
+func IsHTMLMediaType(mediaType string) bool {
+	return mediaType == "text/html" || mediaType == "application/xhtml+xml"
+}
+
+// DetectMediaType combines the declared Content-Type with content sniffing.
+// A body that sniffs as HTML is accepted regardless of the header, and a body
+// declared as HTML is accepted as long as sniffing does not identify it as a
+// non-text format. It returns the declared and sniffed media types (without
+// parameters) along with the verdict.
+func DetectMediaType(contentType string, body []byte) (declared, sniffed string, isHTML bool) {
+	if mt, _, err := mime.ParseMediaType(contentType); err == nil {
+		declared = mt
+	}
+	sniffed, _, _ = mime.ParseMediaType(http.DetectContentType(body))
+
+	switch {
+	case IsHTMLMediaType(sniffed):
+		return declared, sniffed, true
+	case IsHTMLMediaType(declared) && isTextual(sniffed):
+		return declared, sniffed, true
+	}
+	return declared, sniffed, false
+}
+
+func isTextual(mediaType string) bool {
+	return strings.HasPrefix(mediaType, "text/") || strings.HasSuffix(mediaType, "xml")
+}
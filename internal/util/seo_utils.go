@@ -0,0 +1,316 @@
+package util
+
+import (
+	"net/url"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+	"web-analyzer-go/internal/model"
+
+	"golang.org/x/net/html"
+)
+
+// Length limits of the texts search engines show, roughly matching what fits
+// a desktop result before it is cut off. Titles render in 20px Arial and
+// descriptions in 14px Arial.
+const (
+	titleMinChars        = 30
+	titleMaxChars        = 60
+	titleMaxPixels       = 580
+	titleFontPx          = 20
+	descriptionMinChars  = 70
+	descriptionMaxChars  = 160
+	descriptionMaxPixels = 920
+	descriptionFontPx    = 14
+)
+
+// arialWidths are Arial advance widths in thousandths of an em for the
+// printable ASCII range starting at space.
+var arialWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278, // space to /
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, // 0-9
+	278, 278, 584, 584, 584, 556, 1015, // : to @
+	667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, // A-M
+	722, 778, 667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, // N-Z
+	278, 278, 278, 469, 556, 333, // [ to `
+	556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, // a-m
+	556, 556, 556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, // n-z
+	334, 260, 334, 584, // { to ~
+}
+
+// TextPixels estimates the width of s rendered in Arial at fontPx pixels.
+// Characters outside ASCII count as an average lowercase letter, or a full
+// em for wide East Asian scripts.
+func TextPixels(s string, fontPx int) int {
+	total := 0
+	for _, r := range s {
+		switch {
+		case r >= ' ' && r <= '~':
+			total += arialWidths[r-' ']
+		case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul):
+			total += 1000
+		default:
+			total += 556
+		}
+	}
+	return (total*fontPx + 500) / 1000
+}
+
+func measureText(s string, fontPx int) *model.SEOText {
+	return &model.SEOText{Text: s, Chars: utf8.RuneCountInString(s), Pixels: TextPixels(s, fontPx)}
+}
+
+// collapseSpace trims s and collapses its whitespace runs into single spaces,
+// as browsers and search engines display it.
+func collapseSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// headMetadata is what AnalyzeSEO reads from the document, every occurrence
+// in document order.
+type headMetadata struct {
+	titles     []string
+	meta       map[string][]string
+	canonicals []string
+}
+
+func readHeadMetadata(doc *html.Node) headMetadata {
+	md := headMetadata{meta: make(map[string][]string)}
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		// Elements inside <svg> and <math> belong to those documents.
+		if n.Type == html.ElementNode && n.Namespace == "" {
+			switch n.Data {
+			case "title":
+				md.titles = append(md.titles, collapseSpace(textContent(n)))
+			case "meta":
+				var name, content string
+				for _, a := range n.Attr {
+					switch a.Key {
+					case "name":
+						name = strings.ToLower(strings.TrimSpace(a.Val))
+					case "content":
+						content = a.Val
+					}
+				}
+				if name != "" {
+					md.meta[name] = append(md.meta[name], collapseSpace(content))
+				}
+			case "link":
+				var rel, href string
+				for _, a := range n.Attr {
+					switch a.Key {
+					case "rel":
+						rel = strings.ToLower(a.Val)
+					case "href":
+						href = strings.TrimSpace(a.Val)
+					}
+				}
+				if slices.Contains(strings.Fields(rel), "canonical") {
+					md.canonicals = append(md.canonicals, href)
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+	return md
+}
+
+func textContent(n *html.Node) string {
+	var sb strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			sb.WriteString(n.Data)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return sb.String()
+}
+
+// seoReport accumulates the findings of one AnalyzeSEO call.
+type seoReport struct {
+	info model.SEOInfo
+}
+
+func (r *seoReport) flag(f model.SEOFinding) {
+	r.info.Findings = append(r.info.Findings, f)
+	if f.BlocksIndexing {
+		r.info.Indexable = false
+	}
+}
+
+// single reports a missing, empty or repeated field and returns the value of
+// its first occurrence. Repeats of the same value are still duplicates.
+func (r *seoReport) single(field string, values []string, required bool) (string, bool) {
+	if len(values) == 0 {
+		if required {
+			r.flag(model.SEOFinding{Field: field, Issue: model.SEOMissing})
+		}
+		return "", false
+	}
+	if len(values) > 1 {
+		r.flag(model.SEOFinding{Field: field, Issue: model.SEODuplicate, Value: values[1]})
+	}
+	if values[0] == "" {
+		r.flag(model.SEOFinding{Field: field, Issue: model.SEOEmpty})
+		return "", false
+	}
+	return values[0], true
+}
+
+// length flags a text shorter than minChars, or longer than maxChars or
+// maxPixels.
+func (r *seoReport) length(field string, t *model.SEOText, minChars, maxChars, maxPixels int) {
+	issue := ""
+	switch {
+	case t.Chars < minChars:
+		issue = model.SEOTooShort
+	case t.Chars > maxChars || t.Pixels > maxPixels:
+		issue = model.SEOTooLong
+	default:
+		return
+	}
+	r.flag(model.SEOFinding{Field: field, Issue: issue, Chars: t.Chars, Pixels: t.Pixels})
+}
+
+// AnalyzeSEO reports the title, meta description, keywords, viewport,
+// canonical link and robots directives of doc, found at page, and whether the
+// page can be indexed. xRobotsTag holds the X-Robots-Tag response header
+// lines. The canonical link counts as pointing elsewhere when it differs from
+// page once both are normalized with norm; it is not compared when page is not
+// absolute, and conflicting canonical links are ignored, as search engines do.
+func AnalyzeSEO(doc *html.Node, page *url.URL, norm URLNormalizer, xRobotsTag []string) model.SEOInfo {
+	md := readHeadMetadata(doc)
+	r := &seoReport{info: model.SEOInfo{Indexable: true}}
+
+	if title, ok := r.single(model.SEOFieldTitle, md.titles, true); ok {
+		r.info.Title = measureText(title, titleFontPx)
+		r.length(model.SEOFieldTitle, r.info.Title, titleMinChars, titleMaxChars, titleMaxPixels)
+	}
+	if desc, ok := r.single(model.SEOFieldDescription, md.meta["description"], true); ok {
+		r.info.Description = measureText(desc, descriptionFontPx)
+		r.length(model.SEOFieldDescription, r.info.Description, descriptionMinChars, descriptionMaxChars, descriptionMaxPixels)
+	}
+	// Search engines ignore keywords, so their absence is no finding.
+	if keywords, ok := r.single(model.SEOFieldKeywords, md.meta["keywords"], false); ok {
+		for _, k := range strings.Split(keywords, ",") {
+			if k = strings.TrimSpace(k); k != "" {
+				r.info.Keywords = append(r.info.Keywords, k)
+			}
+		}
+	}
+	r.info.Viewport, _ = r.single(model.SEOFieldViewport, md.meta["viewport"], true)
+
+	r.canonical(md.canonicals, DocumentBase(doc, page), page, norm)
+
+	// Every robots meta element applies, so their directives are combined.
+	if len(md.meta["robots"]) > 1 {
+		r.flag(model.SEOFinding{Field: model.SEOFieldRobots, Issue: model.SEODuplicate, Value: md.meta["robots"][1]})
+	}
+	for _, content := range md.meta["robots"] {
+		for _, d := range strings.Split(strings.ToLower(content), ",") {
+			if d = strings.TrimSpace(d); d != "" && !slices.Contains(r.info.Robots, d) {
+				r.info.Robots = append(r.info.Robots, d)
+			}
+		}
+	}
+	if d := noindexDirective(r.info.Robots); d != "" {
+		r.flag(model.SEOFinding{Field: model.SEOFieldRobots, Issue: model.SEONoindex, Value: d, BlocksIndexing: true})
+	}
+
+	generic, directives := parseXRobotsTag(xRobotsTag)
+	r.info.XRobotsTag = directives
+	if d := noindexDirective(generic); d != "" {
+		r.flag(model.SEOFinding{Field: model.SEOFieldXRobotsTag, Issue: model.SEONoindex, Value: d, BlocksIndexing: true})
+	}
+	return r.info
+}
+
+// canonical reports the canonical link, resolved against base, and whether it
+// sends search engines to another URL than page.
+func (r *seoReport) canonical(hrefs []string, base, page *url.URL, norm URLNormalizer) {
+	if len(hrefs) == 0 {
+		r.flag(model.SEOFinding{Field: model.SEOFieldCanonical, Issue: model.SEOMissing})
+		return
+	}
+	resolved := make([]string, 0, len(hrefs))
+	for _, href := range hrefs {
+		u, err := url.Parse(href)
+		if err != nil || href == "" {
+			r.flag(model.SEOFinding{Field: model.SEOFieldCanonical, Issue: model.SEOInvalid, Value: href})
+			continue
+		}
+		u = base.ResolveReference(u)
+		if u.IsAbs() && u.Scheme != "http" && u.Scheme != "https" {
+			r.flag(model.SEOFinding{Field: model.SEOFieldCanonical, Issue: model.SEOInvalid, Value: href})
+			continue
+		}
+		if u.IsAbs() {
+			resolved = append(resolved, norm.Normalize(u))
+		} else {
+			resolved = append(resolved, u.String())
+		}
+	}
+	if len(resolved) == 0 {
+		return
+	}
+	r.info.Canonical = resolved[0]
+	if len(resolved) > 1 {
+		r.flag(model.SEOFinding{Field: model.SEOFieldCanonical, Issue: model.SEODuplicate, Value: resolved[1]})
+	}
+	if slices.ContainsFunc(resolved[1:], func(c string) bool { return c != resolved[0] }) {
+		return
+	}
+	if page.IsAbs() && r.info.Canonical != norm.Normalize(page) {
+		r.flag(model.SEOFinding{Field: model.SEOFieldCanonical, Issue: model.SEOCanonicalized, Value: r.info.Canonical, BlocksIndexing: true})
+	}
+}
+
+// noindexDirective returns the directive among directives that forbids
+// indexing, or "".
+func noindexDirective(directives []string) string {
+	for _, d := range directives {
+		if d == "noindex" || d == "none" {
+			return d
+		}
+	}
+	return ""
+}
+
+// robotsValueDirectives are the X-Robots-Tag directives that take a value
+// after a colon, which must not be mistaken for a user agent.
+var robotsValueDirectives = []string{"unavailable_after", "max-snippet", "max-image-preview", "max-video-preview"}
+
+// parseXRobotsTag splits X-Robots-Tag header lines into the directives that
+// apply to every crawler and the full lowercased list, where a directive
+// scoped to one crawler with an "agent:" prefix keeps it. An agent prefix
+// holds until the end of its header line.
+func parseXRobotsTag(lines []string) (generic, all []string) {
+	for _, line := range lines {
+		agent := ""
+		for _, part := range strings.Split(strings.ToLower(line), ",") {
+			part = strings.TrimSpace(part)
+			if name, rest, ok := strings.Cut(part, ":"); ok && !slices.Contains(robotsValueDirectives, strings.TrimSpace(name)) {
+				agent, part = strings.TrimSpace(name), strings.TrimSpace(rest)
+			}
+			if part == "" {
+				continue
+			}
+			if agent != "" {
+				all = append(all, agent+": "+part)
+				continue
+			}
+			generic = append(generic, part)
+			all = append(all, part)
+		}
+	}
+	return generic, all
+}
@@ -0,0 +1,145 @@
+package util
+
+import (
+	"cmp"
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+	"web-analyzer-go/internal/model"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/publicsuffix"
+)
+
+// DocumentBase returns the URL relative links in doc resolve against: the
+// href of the first <base> element, resolved against page, or page itself
+// when there is none or it is not an http(s) URL.
+func DocumentBase(doc *html.Node, page *url.URL) *url.URL {
+	var href string
+	found := false
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if found {
+			return
+		}
+		if n.Type == html.ElementNode && n.Data == "base" {
+			for _, a := range n.Attr {
+				if a.Key == "href" {
+					href, found = strings.TrimSpace(a.Val), true
+					return
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+	if !found {
+		return page
+	}
+	u, err := url.Parse(href)
+	if err != nil {
+		return page
+	}
+	base := page.ResolveReference(u)
+	if base.Scheme != "http" && base.Scheme != "https" {
+		return page
+	}
+	return base
+}
+
+// SiteRelation classifies target relative to the page it was found on:
+// model.SiteSameHost when the host names match regardless of port,
+// model.SiteSameSite when they share a registrable domain (eTLD+1) per the
+// public suffix list, and model.SiteCrossSite otherwise. IP addresses and
+// hosts without a registrable domain are only ever same-host.
+func SiteRelation(target, page *url.URL) string {
+	th, ph := normalizeHost(target.Hostname()), normalizeHost(page.Hostname())
+	switch {
+	case th == "" || ph == "":
+		return model.SiteCrossSite
+	case th == ph:
+		return model.SiteSameHost
+	}
+	if td := registrableDomain(th); td != "" && td == registrableDomain(ph) {
+		return model.SiteSameSite
+	}
+	return model.SiteCrossSite
+}
+
+func normalizeHost(host string) string {
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
+
+// registrableDomain returns host's eTLD+1, or "" for IP addresses and hosts
+// that are themselves a public suffix.
+func registrableDomain(host string) string {
+	if net.ParseIP(host) != nil {
+		return ""
+	}
+	domain, err := publicsuffix.EffectiveTLDPlusOne(host)
+	if err != nil {
+		return ""
+	}
+	return domain
+}
+
+// SummarizeExternalDomains groups the links not marked internal by
+// registrable domain, busiest first.
+func SummarizeExternalDomains(links []model.LinkDetail) []model.DomainStats {
+	index := make(map[string]int)
+	seen := make(map[string]bool)
+	var domains []model.DomainStats
+	for _, l := range links {
+		if l.Internal {
+			continue
+		}
+		u, err := url.Parse(l.URL)
+		if err != nil {
+			continue
+		}
+		host := normalizeHost(u.Hostname())
+		domain := registrableDomain(host)
+		if domain == "" {
+			domain = host
+		}
+		i, ok := index[domain]
+		if !ok {
+			i = len(domains)
+			index[domain] = i
+			domains = append(domains, model.DomainStats{Domain: domain, HTTPS: true})
+		}
+		d := &domains[i]
+		d.Links += l.Occurrences
+		if !seen[l.URL] {
+			seen[l.URL] = true
+			d.UniqueURLs++
+		}
+		if l.Checked && !l.Accessible && l.Category != model.LinkDisallowedByRobots {
+			d.Inaccessible += l.Occurrences
+		}
+		if finalScheme(l) != "https" {
+			d.HTTPS = false
+		}
+	}
+	slices.SortStableFunc(domains, func(a, b model.DomainStats) int {
+		return cmp.Or(cmp.Compare(b.Links, a.Links), strings.Compare(a.Domain, b.Domain))
+	})
+	return domains
+}
+
+// finalScheme is the scheme a link was reached over: its redirect target's
+// when it was checked and redirected, otherwise its own.
+func finalScheme(l model.LinkDetail) string {
+	target := l.URL
+	if l.Checked && l.RedirectURL != "" {
+		target = l.RedirectURL
+	}
+	u, err := url.Parse(target)
+	if err != nil {
+		return ""
+	}
+	return u.Scheme
+}
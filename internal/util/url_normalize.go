@@ -0,0 +1,112 @@
+package util
+
+import (
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+// DefaultTrackingParams are the query parameters dropped from links when none
+// are configured. A trailing * matches any suffix.
+var DefaultTrackingParams = []string{
+	"utm_*", "fbclid", "gclid", "dclid", "msclkid", "yclid", "igshid", "mc_cid", "mc_eid", "_hsenc", "_hsmi",
+}
+
+// URLNormalizer canonicalizes absolute URLs so that spellings of the same
+// link are checked and reported once. It lowercases the scheme and host,
+// converts internationalized host names to punycode, drops default ports, the
+// fragment and any query parameter matching TrackingParams (case-insensitive,
+// a trailing * matching any suffix), and with SortQuery orders the remaining
+// parameters by name.
+type URLNormalizer struct {
+	TrackingParams []string
+	SortQuery      bool
+}
+
+// DefaultURLNormalizer drops DefaultTrackingParams and keeps the query order.
+func DefaultURLNormalizer() URLNormalizer {
+	return URLNormalizer{TrackingParams: DefaultTrackingParams}
+}
+
+// Normalize returns the canonical form of the absolute URL u.
+func (n URLNormalizer) Normalize(u *url.URL) string {
+	v := *u
+	v.Scheme = strings.ToLower(v.Scheme)
+	v.Host = normalizeURLHost(v.Scheme, v.Hostname(), v.Port())
+	v.Fragment, v.RawFragment = "", ""
+	if v.Path == "" && v.Opaque == "" {
+		v.Path, v.RawPath = "/", ""
+	}
+	v.RawQuery = n.normalizeQuery(v.RawQuery)
+	v.ForceQuery = false
+	return v.String()
+}
+
+// normalizeURLHost lowercases host, converts it to its ASCII form and drops
+// the port when it is the scheme's default.
+func normalizeURLHost(scheme, host, port string) string {
+	host = strings.ToLower(host)
+	if ascii, err := idna.Lookup.ToASCII(host); err == nil {
+		host = ascii
+	}
+	if (scheme == "http" && port == "80") || (scheme == "https" && port == "443") {
+		port = ""
+	}
+	switch {
+	case port != "":
+		return net.JoinHostPort(host, port)
+	case strings.Contains(host, ":"):
+		return "[" + host + "]"
+	default:
+		return host
+	}
+}
+
+// normalizeQuery drops tracking parameters and empty pairs from raw, keeping
+// the remaining pairs as written, and sorts them by name with SortQuery.
+func (n URLNormalizer) normalizeQuery(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	type pair struct{ key, raw string }
+	var pairs []pair
+	for _, p := range strings.Split(raw, "&") {
+		if p == "" {
+			continue
+		}
+		key, _, _ := strings.Cut(p, "=")
+		if k, err := url.QueryUnescape(key); err == nil {
+			key = k
+		}
+		if n.isTracking(key) {
+			continue
+		}
+		pairs = append(pairs, pair{key: key, raw: p})
+	}
+	if n.SortQuery {
+		slices.SortStableFunc(pairs, func(a, b pair) int { return strings.Compare(a.key, b.key) })
+	}
+	kept := make([]string, len(pairs))
+	for i, p := range pairs {
+		kept[i] = p.raw
+	}
+	return strings.Join(kept, "&")
+}
+
+func (n URLNormalizer) isTracking(key string) bool {
+	key = strings.ToLower(key)
+	for _, pattern := range n.TrackingParams {
+		pattern = strings.ToLower(pattern)
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(key, prefix) {
+				return true
+			}
+		} else if key == pattern {
+			return true
+		}
+	}
+	return false
+}